@@ -0,0 +1,194 @@
+package mux
+
+import (
+	"strings"
+)
+
+// translateBraces rewrites brace placeholders of a pattern into the
+// colon syntax, segments without braces are kept as they are.
+//
+//  {id}          :id
+//  {id?}         ?:id
+//  {id:[0-9]+}   :id(^(?:[0-9]+)$)
+//  {path...}     *path
+//  {$}           the empty last segment, "/a/{$}" is "/a/"
+//  cms_{id}.html cms_:id(.+).html
+//
+// The spans map the translated pattern back to the pattern, see braceColumn.
+func translateBraces(pattern string) (string, []braceSpan, error) {
+	segments := strings.Split(pattern, "/")
+	var spans []braceSpan
+	from, to := 0, 0
+	for i, segment := range segments {
+		// the slash before the segment, and the segment if it is kept
+		if i > 0 {
+			spans = append(spans, braceSpan{to: to - 1, from: from - 1})
+		} else {
+			spans = append(spans, braceSpan{to: to, from: from})
+		}
+		if strings.ContainsAny(segment, "{}") {
+			s, segmentSpans, err := translateBraceSegment(segment, i == len(segments)-1)
+			if err != nil {
+				err.Pattern = pattern
+				err.Column += from
+				return "", nil, err
+			}
+			for _, span := range segmentSpans {
+				span.to += to
+				span.from += from
+				spans = append(spans, span)
+			}
+			segments[i] = s
+		}
+		from += len(segment) + 1
+		to += len(segments[i]) + 1
+	}
+	return strings.Join(segments, "/"), spans, nil
+}
+
+// braceSpan is the start of a text or a placeholder in a translated pattern,
+// to is its offset in the translated pattern and from in the pattern.
+type braceSpan struct {
+	to, from    int
+	placeholder bool
+}
+
+// braceColumn returns the column in the pattern of a column of its
+// translation: the same character of a text, or the opening brace of a
+// placeholder.
+//
+//  /users/{id:[z-a]}   column 8
+//  /users/:id(^(?:[z-a])$) column 11
+func braceColumn(spans []braceSpan, column int) int {
+	for i := len(spans) - 1; i >= 0; i-- {
+		if span := spans[i]; span.to <= column-1 {
+			if span.placeholder {
+				return span.from + 1
+			}
+			return span.from + column - span.to
+		}
+	}
+	return column
+}
+
+func translateBraceSegment(segment string, last bool) (string, []braceSpan, *ErrInvalidPattern) {
+	fail := func(i int, reason string) (string, []braceSpan, *ErrInvalidPattern) {
+		return "", nil, &ErrInvalidPattern{Pattern: segment, Column: i + 1, Reason: reason}
+	}
+	var (
+		result []string
+		spans  []braceSpan
+		length int
+		whole  bool
+	)
+	add := func(s string, from int, placeholder bool) {
+		spans = append(spans, braceSpan{to: length, from: from, placeholder: placeholder})
+		result = append(result, s)
+		length += len(s)
+	}
+	for i := 0; i < len(segment); {
+		switch segment[i] {
+		case '}':
+			return fail(i, "unexpected }")
+		case '{':
+		default:
+			j := strings.IndexByte(segment[i:], '{')
+			if j < 0 {
+				j = len(segment) - i
+			}
+			text := segment[i : i+j]
+			if k := strings.IndexAny(text, ":}"); k >= 0 {
+				return fail(i+k, "static text beside a placeholder can not contain : or }")
+			}
+			add(text, i, false)
+			i += j
+			continue
+		}
+		// find the closing brace, regexps may contain braces too
+		end, depth := -1, 0
+		for j := i; j < len(segment) && end < 0; j++ {
+			switch segment[j] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					end = j
+				}
+			}
+		}
+		if end < 0 {
+			return fail(i, "missing }")
+		}
+		whole = i == 0 && end == len(segment)-1
+		name, expr := segment[i+1:end], ""
+		if k := strings.IndexByte(name, ':'); k >= 0 {
+			name, expr = name[:k], name[k+1:]
+		}
+		optional := strings.HasSuffix(name, "?")
+		name = strings.TrimSuffix(name, "?")
+		switch {
+		case name == "$" && expr == "":
+			if !whole || !last {
+				return fail(i, "{$} must be the last segment")
+			}
+			return "", nil, nil
+		case strings.HasSuffix(name, "...") && expr == "" && !optional:
+			name = strings.TrimSuffix(name, "...")
+			if !whole || !wordRegexp.MatchString(name) {
+				return fail(i, "{name...} must be a whole segment")
+			}
+			return "*" + name, []braceSpan{{placeholder: true}}, nil
+		case !wordRegexp.MatchString(name):
+			return fail(i, "wrong param name "+segment[i:end+1])
+		case strings.Contains(expr, "/"):
+			return fail(i, "param regexp can not contain /")
+		case capturingGroup(expr) >= 0:
+			return fail(i, "param regexp can not contain capturing groups, use (?:...)")
+		}
+		prefix := ":"
+		if optional {
+			prefix = "?:"
+		}
+		switch {
+		case whole && expr == "":
+			add(prefix+name, i, true)
+		case whole:
+			add(prefix+name+"(^(?:"+expr+")$)", i, true)
+		case expr == "":
+			add(prefix+name+"(.+)", i, true)
+		default:
+			add(prefix+name+"("+expr+")", i, true)
+		}
+		i = end + 1
+	}
+	return strings.Join(result, ""), spans, nil
+}
+
+// capturingGroup returns the index of the first capturing group of the
+// regexp, -1 if it has none. The param is the only group of a placeholder,
+// its regexp can only have non-capturing groups such as (?:a|b).
+func capturingGroup(expr string) int {
+	class := false
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '\\':
+			i++
+		case class:
+			class = c != ']'
+		case c == '[':
+			// a ] right after [ or [^ is a literal
+			class = true
+			if strings.HasPrefix(expr[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(expr[i+1:], "]") {
+				i++
+			}
+		case c == '(':
+			if !strings.HasPrefix(expr[i+1:], "?") || strings.HasPrefix(expr[i+1:], "?P<") || strings.HasPrefix(expr[i+1:], "?<") {
+				return i
+			}
+		}
+	}
+	return -1
+}
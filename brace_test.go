@@ -0,0 +1,158 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTranslateBraces(t *testing.T) {
+	items := map[string]string{
+		"/users/:id":                "/users/:id",
+		"/users/{id}":               "/users/:id",
+		"/users/{id}/:name":         "/users/:id/:name",
+		"/users/{id?}":              "/users/?:id",
+		"/users/{id:[0-9]+}":        "/users/:id(^(?:[0-9]+)$)",
+		"/users/{id:[0-9]{3}}":      "/users/:id(^(?:[0-9]{3})$)",
+		"/lang/{lang:en|fr}":        "/lang/:lang(^(?:en|fr)$)",
+		"/c/{cat:(?:a|b)x}":         "/c/:cat(^(?:(?:a|b)x)$)",
+		"/c/{cat:[(]x}":             "/c/:cat(^(?:[(]x)$)",
+		"/files/{path...}":          "/files/*path",
+		"/users/{$}":                "/users/",
+		"/cms_{id}.html":            "/cms_:id(.+).html",
+		"/cms_{id:[0-9]+}_{page}":   "/cms_:id([0-9]+)_:page(.+)",
+		"/v1/{year}/{month}/{slug}": "/v1/:year/:month/:slug",
+	}
+	for pattern, expect := range items {
+		got, _, err := translateBraces(pattern)
+		if err != nil || got != expect {
+			t.Fatalf("%s should translate to %s, got %s, %v", pattern, expect, got, err)
+		}
+	}
+
+	errors := map[string]int{
+		"/users/{id":           8,
+		"/users/id}":           10,
+		"/users/{i-d}":         8,
+		"/users/{$}/posts":     8,
+		"/files/{path...}.gz":  8,
+		"/users/{id:(a|b)}":    8,
+		"/users/{id:(?P<n>a)}": 8,
+		"/users/{id:a/b}":      8,
+		"/users/{name}:cancel": 14,
+	}
+	for pattern, column := range errors {
+		_, _, err := translateBraces(pattern)
+		if e, ok := err.(*ErrInvalidPattern); !ok || e.Column != column || e.Pattern != pattern {
+			t.Fatalf("%s should fail at column %d, got %v", pattern, column, err)
+		}
+	}
+}
+
+func TestBraceSyntax(t *testing.T) {
+	routes := []struct {
+		pattern    string
+		requesturl string
+		params     map[string]string
+	}{
+		{"/users/{id:[0-9]+}", "/users/123", map[string]string{":id": "123"}},
+		{"/people/{name}", "/people/abc123", map[string]string{":name": "abc123"}},
+		{"/files/{path...}", "/files/a/b.txt", map[string]string{":path": "a/b.txt"}},
+		{"/posts/{slug}/:page:int", "/posts/hello/2", map[string]string{":slug": "hello", ":page": "2"}},
+	}
+	tr := NewTrie(Options{CaseSensitive: true, BraceSyntax: true})
+	for _, r := range routes {
+		tr.Parse(r.pattern).Handle("GET", r.pattern)
+	}
+	for _, r := range routes {
+		m, err := tr.Match(r.requesturl)
+		if err != nil || m.Node == nil || m.Node.GetHandler("GET").(string) != r.pattern {
+			t.Fatalf("%s should match %s, got %#v, %v", r.requesturl, r.pattern, m, err)
+		}
+		for k, v := range r.params {
+			if m.Params[k] != v {
+				t.Fatalf("%s should have %s=%s, got %v", r.requesturl, k, v, m.Params)
+			}
+		}
+	}
+	if tr.Parse("/users/{id:[0-9]+}") != tr.Parse("/users/:id(^(?:[0-9]+)$)") {
+		t.Fatal("brace and colon syntax should share nodes")
+	}
+}
+
+func TestBracePattern(t *testing.T) {
+	tr := NewTrie(Options{CaseSensitive: true, BraceSyntax: true})
+	node := tr.Parse("/users/{id:[0-9]+}/posts/{slug}")
+	if node.GetPattern() != "/users/{id:[0-9]+}/posts/{slug}" || node.GetColonPattern() != "/users/:id(^(?:[0-9]+)$)/posts/:slug" {
+		t.Fatalf("should keep the pattern as registered, got %q and %q", node.GetPattern(), node.GetColonPattern())
+	}
+	u, err := node.BuildURL(":id", "12", ":slug", "hello")
+	if err != nil || u.Path != "/users/12/posts/hello" {
+		t.Fatalf("should build the URL, got %v %v", u, err)
+	}
+
+	// columns of the errors found in the translation are in the pattern
+	errors := map[string]int{
+		"/users/{id:[z-a]}":        8,
+		"/a/{id}/b//c":             10,
+		"/cms_{id}_{x:[z-a]}.html": 11,
+	}
+	for pattern, column := range errors {
+		_, err := tr.TryParse(pattern)
+		if e, ok := err.(*ErrInvalidPattern); !ok || e.Column != column || e.Pattern != pattern {
+			t.Fatalf("%s should fail at column %d, got %v", pattern, column, err)
+		}
+	}
+
+	mx := New(Options{CaseSensitive: true, BraceSyntax: true})
+	var route *Route
+	mx.Get("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		route = RouteFromRequest(r)
+	})
+	mx.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/12", nil))
+	if route == nil || route.Pattern != "/users/{id:[0-9]+}" || route.Params[":id"] != "12" {
+		t.Fatalf("should serve the route with its pattern as registered, got %+v", route)
+	}
+}
+
+func TestBraceRegexpGroups(t *testing.T) {
+	tr := NewTrie(Options{CaseSensitive: true, BraceSyntax: true})
+	lang := tr.Parse("/lang/{lang:en|fr}")
+	lang.Handle("GET", "lang")
+	cat := tr.Parse("/cat/{cat:(?:a|b)x}")
+	cat.Handle("GET", "cat")
+	items := []struct {
+		path  string
+		node  *Node
+		param string
+	}{
+		{"/lang/en", lang, "en"},
+		{"/lang/fr", lang, "fr"},
+		{"/lang/english", nil, ""},
+		{"/lang/xfr", nil, ""},
+		{"/cat/ax", cat, "ax"},
+		{"/cat/bx", cat, "bx"},
+		{"/cat/abx", nil, ""},
+		// the group matches the segment without its extension
+		{"/lang/fr.json", lang, "fr"},
+	}
+	for _, v := range items {
+		m, err := tr.Match(v.path)
+		if err != nil || m.Node != v.node {
+			t.Fatalf("%s: should match %v, got %+v %v", v.path, v.node, m, err)
+		}
+		if v.node != nil && m.Params[":"+v.node.GetHandler("GET").(string)] != v.param {
+			t.Fatalf("%s: should have the param %q, got %v", v.path, v.param, m.Params)
+		}
+	}
+
+	// the URL is built in the whole group of the param
+	u, err := lang.BuildURL(":lang", "fr")
+	if err != nil || u.Path != "/lang/fr" {
+		t.Fatalf("should build /lang/fr, got %v %v", u, err)
+	}
+	u, err = cat.BuildURL(":cat", "bx")
+	if err != nil || u.Path != "/cat/bx" {
+		t.Fatalf("should build /cat/bx, got %v %v", u, err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build go1.8
+// +build go1.8
+
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestPathEscape(t *testing.T) {
+	var all []byte
+	for c := 0; c < 256; c++ {
+		all = append(all, byte(c))
+	}
+	for _, s := range []string{"", "a/b c?d#e;f,g%h", "é", string(all)} {
+		if got, expected := pathEscape(s), url.PathEscape(s); got != expected {
+			t.Fatalf("%q: should escape to %q, got %q", s, expected, got)
+		}
+	}
+}
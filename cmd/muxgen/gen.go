@@ -0,0 +1,1156 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/beego/mux"
+)
+
+// route is a route of the table with its methods, endpoint is its node in
+// the trie of mux and original its pattern as registered.
+type route struct {
+	name     string
+	original string
+	methods  []string
+	any      bool
+	priority int
+	ident    string
+	endpoint *mux.Node
+	node     *node
+}
+
+type nodeKind int
+
+const (
+	kindStatic nodeKind = iota
+	kindParam
+	kindOptional
+	kindRegexp
+	kindWildcard
+	kindExtWildcard
+)
+
+// node is a node of the trie built by mux for the patterns, with its
+// children in the order they are tried by Trie.Match.
+type node struct {
+	id       int
+	segment  string
+	kind     nodeKind
+	names    []string
+	infos    []mux.ParamInfo
+	optional bool
+	// check is how a regexp segment is matched: "int" or "word" for a
+	// single :int or :string param, or "regexp" for a compiled regexp.
+	check   string
+	expr    string
+	parts   []part
+	parent  *node
+	static  map[string]*node
+	params  []*node
+	options []*node
+	vary    []*node
+	route   *route
+	rank    int
+	order   int
+}
+
+// part is a literal or a param of a regexp segment, to build URLs as
+// Node.BuildURL does.
+type part struct {
+	literal, name string
+}
+
+// tree builds the nodes of the routes, in adding order.
+type tree struct {
+	root  *node
+	nodes []*node
+}
+
+func newTree(routes []*route) *tree {
+	t := &tree{}
+	t.root = t.newNode(nil, "")
+	t.root.kind = kindStatic
+	for _, r := range routes {
+		var path []*mux.Node
+		for mn := r.endpoint; mn.Parent() != nil; mn = mn.Parent() {
+			path = append([]*mux.Node{mn}, path...)
+		}
+		n := t.root
+		for _, mn := range path {
+			n = t.child(n, mn)
+		}
+		n.route = r
+		r.node = n
+	}
+	t.root.sort()
+	return t
+}
+
+func (t *tree) newNode(parent *node, segment string) *node {
+	n := &node{id: len(t.nodes), segment: segment, parent: parent, static: make(map[string]*node)}
+	if parent != nil {
+		n.order = len(parent.params) + len(parent.options) + len(parent.vary)
+	}
+	t.nodes = append(t.nodes, n)
+	return n
+}
+
+// lookup returns the child of n for the segment as Node.getChild does.
+func (n *node) lookup(segment string) *node {
+	key := strings.Replace(segment, "::", ":", -1)
+	if c, ok := n.static[key]; ok {
+		return c
+	}
+	for _, list := range [][]*node{n.params, n.options, n.vary} {
+		for _, c := range list {
+			if c.segment == key {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// child returns the child of n for the node of mux, added to the children
+// of its kind as parseSegment does.
+func (t *tree) child(n *node, mn *mux.Node) *node {
+	segment := mn.Segment()
+	if c := n.lookup(segment); c != nil {
+		return c
+	}
+	c := t.newNode(n, segment)
+	params := mn.SegmentParams()
+	for _, p := range params {
+		c.names = append(c.names, p.Name)
+	}
+	c.infos = params
+	switch {
+	case mn.Kind() == mux.KindStatic:
+		n.static[strings.Replace(segment, "::", ":", -1)] = c
+	case mn.Kind() == mux.KindWildcard && len(params) == 2:
+		c.kind = kindExtWildcard
+		n.vary = append(n.vary, c)
+	case mn.Kind() == mux.KindWildcard:
+		c.kind = kindWildcard
+		n.vary = append(n.vary, c)
+	case mn.Regexp() == "" && mn.Kind() == mux.KindOptional:
+		c.kind, c.optional = kindOptional, true
+		n.options = append(n.options, c)
+		n.params = append(n.params, c)
+	case mn.Kind() == mux.KindParam:
+		c.kind = kindParam
+		n.params = append(n.params, c)
+	default:
+		c.kind, c.expr, c.optional = kindRegexp, mn.Regexp(), mn.Kind() == mux.KindOptional
+		c.parts = regexpParts(c.expr, params)
+		switch c.expr {
+		case "([0-9]+)":
+			c.check = "int"
+		case `([\w]+)`:
+			c.check = "word"
+		default:
+			c.check = "regexp"
+		}
+		if c.optional {
+			n.options = append(n.options, c)
+		}
+		n.vary = append(n.vary, c)
+	}
+	return c
+}
+
+// sort computes the ranks as Node.Priority does and orders the children by
+// descending rank, then adding order.
+func (n *node) sort() int {
+	n.rank = 0
+	if n.route != nil {
+		n.rank = n.route.priority
+	}
+	for _, c := range n.children() {
+		if r := c.sort(); r > n.rank {
+			n.rank = r
+		}
+	}
+	for _, list := range [][]*node{n.params, n.options, n.vary} {
+		for i := 1; i < len(list); i++ {
+			for j := i; j > 0 && (list[j-1].rank < list[j].rank ||
+				list[j-1].rank == list[j].rank && list[j-1].order > list[j].order); j-- {
+				list[j-1], list[j] = list[j], list[j-1]
+			}
+		}
+	}
+	return n.rank
+}
+
+// children returns the static children sorted by segment, then params and
+// regexp params. Optional params are in params or vary.
+func (n *node) children() []*node {
+	var children []*node
+	for _, key := range n.staticKeys() {
+		children = append(children, n.static[key])
+	}
+	children = append(children, n.params...)
+	return append(children, n.vary...)
+}
+
+func (n *node) staticKeys() []string {
+	keys := make([]string, 0, len(n.static))
+	for key := range n.static {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// regexpParts splits the regexp of a segment into its literals and params,
+// replacing the first group after the previous param by each param as
+// Node.BuildURL does.
+func regexpParts(expr string, params []mux.ParamInfo) []part {
+	var parts []part
+	pos := 0
+	for _, p := range params {
+		start := strings.IndexByte(expr[pos:], '(')
+		if start < 0 {
+			break
+		}
+		start += pos
+		end := groupEnd(expr, start)
+		if end < 0 {
+			break
+		}
+		if start > pos {
+			parts = append(parts, part{literal: expr[pos:start]})
+		}
+		parts = append(parts, part{name: p.Name})
+		pos = end + 1
+	}
+	if pos < len(expr) {
+		parts = append(parts, part{literal: expr[pos:]})
+	}
+	return parts
+}
+
+// groupEnd returns the index of the parenthesis closing the group opened at
+// start, as in mux: the group of a brace regexp has groups inside, such as
+// (^(?:a|b)$).
+func groupEnd(expr string, start int) int {
+	depth, class := 0, false
+	for i := start; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '\\':
+			i++
+		case class:
+			class = c != ']'
+		case c == '[':
+			// a ] right after [ or [^ is a literal
+			class = true
+			if strings.HasPrefix(expr[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(expr[i+1:], "]") {
+				i++
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ident returns an exported Go identifier of the words of s.
+func ident(s string) string {
+	var b bytes.Buffer
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "R" + id
+	}
+	return id
+}
+
+// routeIdent returns the identifier of an unnamed route, the words of its
+// static segments and param names.
+func routeIdent(r *route) string {
+	var words []string
+	for _, n := range r.node.nodes() {
+		if n.kind == kindStatic {
+			words = append(words, n.segment)
+		} else {
+			words = append(words, n.names...)
+		}
+	}
+	if id := ident(strings.Join(words, " ")); id != "R" {
+		return id
+	}
+	return "Root"
+}
+
+var reserved = map[string]bool{
+	"NotFound": true, "Route": true, "Param": true, "Params": true, "Match": true,
+}
+
+// setIdents names the Route constants of the routes.
+func setIdents(routes []*route) error {
+	taken := make(map[string]bool)
+	for k := range reserved {
+		taken[k] = true
+	}
+	for _, r := range routes {
+		base := routeIdent(r)
+		if r.name != "" {
+			base = ident(r.name)
+		}
+		r.ident = base
+		for i := 2; taken[r.ident]; i++ {
+			r.ident = fmt.Sprintf("%s%d", base, i)
+		}
+		taken[r.ident] = true
+	}
+	for _, r := range routes {
+		if r.name == "" {
+			continue
+		}
+		for _, id := range []string{r.ident + "Params", "Parse" + r.ident + "Params", r.ident + "URL"} {
+			if taken[id] {
+				return fmt.Errorf("route %q: identifier %s is already used, rename the route", r.name, id)
+			}
+			taken[id] = true
+		}
+	}
+	return nil
+}
+
+// field is a field of the params struct of a named route.
+type field struct {
+	name, param               string
+	isInt, optional, wildcard bool
+}
+
+// fields returns the params of the route pattern as struct fields.
+// Optional params are strings, empty when missing, or *int, nil when
+// missing.
+func fields(r *route) []field {
+	var fs []field
+	seen := make(map[string]bool)
+	add := func(param string, isInt, optional, wildcard bool) {
+		if seen[param] {
+			return
+		}
+		seen[param] = true
+		name := ident(param)
+		for i := 2; fieldTaken(fs, name); i++ {
+			name = fmt.Sprintf("%s%d", ident(param), i)
+		}
+		fs = append(fs, field{name: name, param: param, isInt: isInt, optional: optional, wildcard: wildcard})
+	}
+	for _, n := range r.node.nodes() {
+		for _, p := range n.infos {
+			// the path of *.* spans segments as a wildcard, not its extension
+			add(p.Name, n.kind == kindRegexp && p.Type == "int", p.Optional,
+				p.Type == "wildcard" && p.Name != ":ext")
+		}
+	}
+	return fs
+}
+
+func fieldTaken(fs []field, name string) bool {
+	for _, f := range fs {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// generate returns the gofmt'ed Go source of the matcher of the routes.
+func generate(pkg, source string, routes []*route) ([]byte, error) {
+	t := newTree(routes)
+	if err := setIdents(routes); err != nil {
+		return nil, err
+	}
+	g := &generator{tree: t, routes: routes}
+	g.file(pkg, source)
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code: %s", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	buf    bytes.Buffer
+	tree   *tree
+	routes []*route
+}
+
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) file(pkg, source string) {
+	hasRegexp, hasInt, hasString, hasOptionalInt := false, false, false, false
+	for _, n := range g.tree.nodes {
+		hasRegexp = hasRegexp || n.check == "regexp"
+	}
+	for _, r := range g.routes {
+		for _, f := range fields(r) {
+			hasInt = hasInt || r.name != "" && f.isInt
+			hasString = hasString || r.name != "" && !f.isInt
+			hasOptionalInt = hasOptionalInt || r.name != "" && f.isInt && f.optional
+		}
+	}
+
+	g.p("// Code generated by muxgen from %s. DO NOT EDIT.", source)
+	g.p("")
+	g.p("package %s", pkg)
+	g.p("")
+	g.p("import (")
+	if hasRegexp {
+		g.p(`"regexp"`)
+	}
+	if hasInt {
+		g.p(`"strconv"`)
+	}
+	g.p(`"strings"`)
+	g.p(")")
+	g.p("")
+	g.routeConstants()
+	g.buf.WriteString(runtimeSource)
+	g.regexps()
+	g.next()
+	g.bind()
+	g.wildcard()
+	g.finish()
+	for _, r := range g.routes {
+		if r.name != "" {
+			g.builders(r)
+		}
+	}
+	if hasString {
+		g.buf.WriteString(pathEscapeSource)
+	}
+	if hasOptionalInt {
+		g.buf.WriteString(formatIntSource)
+	}
+}
+
+func (g *generator) routeConstants() {
+	g.p("// Route is a route of the table, NotFound if no route matches.")
+	g.p("type Route int")
+	g.p("")
+	g.p("const (")
+	g.p("NotFound Route = iota")
+	for _, r := range g.routes {
+		g.p("// %s is %s %s.", r.ident, strings.Join(r.allMethods(), ","), r.original)
+		g.p("%s", r.ident)
+	}
+	g.p(")")
+	g.p("")
+	g.p("var routePatterns = [%d]string{", len(g.routes)+1)
+	for _, r := range g.routes {
+		g.p("%s: %q,", r.ident, r.original)
+	}
+	g.p("}")
+	g.p("")
+	g.p("var routeNames = [%d]string{", len(g.routes)+1)
+	for _, r := range g.routes {
+		if r.name != "" {
+			g.p("%s: %q,", r.ident, r.name)
+		}
+	}
+	g.p("}")
+	g.p("")
+	g.p("var routeAllow = [%d][]string{", len(g.routes)+1)
+	for _, r := range g.routes {
+		if len(r.methods) > 0 {
+			g.p("%s: {%s},", r.ident, quoteAll(r.methods))
+		}
+	}
+	g.p("}")
+	g.p("")
+	g.p("var routeAny = [%d]bool{", len(g.routes)+1)
+	for _, r := range g.routes {
+		if r.any {
+			g.p("%s: true,", r.ident)
+		}
+	}
+	g.p("}")
+	g.p("")
+	g.p("// nodeRoutes are the routes of the nodes, by node id.")
+	g.p("var nodeRoutes = [%d]Route{", len(g.tree.nodes))
+	for _, n := range g.tree.nodes {
+		if n.route != nil {
+			g.p("%d: %s,", n.id, n.route.ident)
+		}
+	}
+	g.p("}")
+	g.p("")
+}
+
+func (r *route) allMethods() []string {
+	if r.any {
+		return append(append([]string(nil), r.methods...), "*")
+	}
+	return r.methods
+}
+
+func quoteAll(ss []string) string {
+	q := make([]string, len(ss))
+	for i, s := range ss {
+		q[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(q, ", ")
+}
+
+func (g *generator) regexps() {
+	var exprs []string
+	for _, n := range g.tree.nodes {
+		if n.check == "regexp" {
+			exprs = append(exprs, fmt.Sprintf("re%d = regexp.MustCompile(%s) // %s", n.id, quoteRegexp(n.expr), n.segment))
+		}
+	}
+	if len(exprs) == 0 {
+		return
+	}
+	g.p("var (")
+	for _, e := range exprs {
+		g.p("%s", e)
+	}
+	g.p(")")
+	g.p("")
+}
+
+// quoteRegexp returns a raw string literal of s if possible.
+func quoteRegexp(s string) string {
+	if strings.ContainsAny(s, "`\n\r") {
+		return fmt.Sprintf("%q", s)
+	}
+	return "`" + s + "`"
+}
+
+// next writes the child selection of every node, as matchNode.
+func (g *generator) next() {
+	g.p("// next returns the child of the node matching the segment, -1 if none,")
+	g.p("// rest is the path after the segment.")
+	g.p("func next(node int, seg, rest string) int {")
+	g.p("switch node {")
+	for _, n := range g.tree.nodes {
+		if len(n.static) == 0 && len(n.params) == 0 && len(n.vary) == 0 {
+			continue
+		}
+		g.p("case %d: // %s", n.id, n.path())
+		g.nodeNext(n)
+	}
+	g.p("}")
+	g.p("return -1")
+	g.p("}")
+	g.p("")
+}
+
+func (g *generator) nodeNext(n *node) {
+	// static children, and params by their literal segment
+	cases := make(map[string]*node)
+	var keys []string
+	for _, key := range n.staticKeys() {
+		cases[key] = n.static[key]
+		keys = append(keys, key)
+	}
+	for _, list := range [][]*node{n.params, n.options, n.vary} {
+		for _, c := range list {
+			if _, ok := cases[c.segment]; !ok {
+				cases[c.segment] = c
+				keys = append(keys, c.segment)
+			}
+		}
+	}
+	if len(keys) > 0 {
+		colon := false
+		for _, key := range keys {
+			colon = colon || strings.Contains(key, ":")
+		}
+		if colon {
+			g.p("switch staticKey(seg) {")
+		} else {
+			g.p("switch seg {")
+		}
+		for _, key := range keys {
+			g.p("case %q:", key)
+			g.p("return %d", cases[key].id)
+		}
+		g.p("}")
+	}
+	for _, c := range n.params {
+		hasChildren := len(c.static) > 0 || len(c.params) > 0 || len(c.vary) > 0
+		canEnd := c.route != nil || len(c.options) > 0
+		// only an optional param matches the empty last segment
+		var cond string
+		switch {
+		case hasChildren && canEnd && c.optional:
+			cond = "seg != \"\" || rest == \"\""
+		case hasChildren && canEnd:
+			cond = "seg != \"\""
+		case hasChildren:
+			cond = "seg != \"\" && rest != \"\""
+		case canEnd && c.optional:
+			cond = "rest == \"\""
+		case canEnd:
+			cond = "seg != \"\" && rest == \"\""
+		default:
+			continue
+		}
+		g.p("if %s {", cond)
+		g.p("return %d // %s", c.id, c.segment)
+		g.p("}")
+	}
+	for _, c := range n.vary {
+		if c.optional {
+			g.p("if seg == \"\" && rest == \"\" {")
+			g.p("return %d // %s", c.id, c.segment)
+			g.p("}")
+		}
+		switch {
+		case c.kind == kindExtWildcard:
+			g.p("if extWildcard(seg, rest) {")
+		case c.kind == kindWildcard:
+			g.p("if wildcardSegment(seg) {")
+		case c.check == "int":
+			g.p("if digits(seg) != \"\" {")
+		case c.check == "word":
+			g.p("if word(seg) != \"\" {")
+		default:
+			g.p("if re%d.MatchString(seg) {", c.id)
+		}
+		g.p("return %d // %s", c.id, c.segment)
+		g.p("}")
+	}
+}
+
+// nodes returns the nodes from the root to n, without the root.
+func (n *node) nodes() []*node {
+	var nodes []*node
+	for ; n.parent != nil; n = n.parent {
+		nodes = append([]*node{n}, nodes...)
+	}
+	return nodes
+}
+
+// path returns the pattern of the node.
+func (n *node) path() string {
+	if n.parent == nil {
+		return "/"
+	}
+	if n.parent.parent == nil {
+		return "/" + n.segment
+	}
+	return n.parent.path() + "/" + n.segment
+}
+
+// bind writes the params of the param and regexp nodes.
+func (g *generator) bind() {
+	g.p("// bind adds the params of the node matching the segment.")
+	g.p("func bind(node int, seg string, params *Params) {")
+	g.p("switch node {")
+	for _, n := range g.tree.nodes {
+		switch {
+		case n.kind == kindParam || n.kind == kindOptional:
+			g.p("case %d: // %s", n.id, n.path())
+			g.p("params.set(%q, seg)", n.names[0])
+		case n.kind == kindRegexp && n.check == "int":
+			g.p("case %d: // %s", n.id, n.path())
+			g.p("params.set(%q, digits(seg))", n.names[0])
+		case n.kind == kindRegexp && n.check == "word":
+			g.p("case %d: // %s", n.id, n.path())
+			g.p("params.set(%q, word(seg))", n.names[0])
+		case n.kind == kindRegexp:
+			g.p("case %d: // %s", n.id, n.path())
+			g.p("if m := re%d.FindStringSubmatch(seg); m != nil {", n.id)
+			for i, name := range n.names {
+				g.p("params.set(%q, m[%d])", name, i+1)
+			}
+			if n.optional {
+				g.p("} else {")
+				for _, name := range n.names {
+					g.p("params.set(%q, \"\")", name)
+				}
+			}
+			g.p("}")
+		}
+	}
+	g.p("}")
+	g.p("}")
+	g.p("")
+}
+
+func (g *generator) wildcard() {
+	g.p("// wildcard returns the param of a wildcard node, \"*.*\" for a *.* node.")
+	g.p("func wildcard(node int) string {")
+	g.p("switch node {")
+	for _, n := range g.tree.nodes {
+		switch n.kind {
+		case kindWildcard:
+			g.p("case %d: // %s", n.id, n.path())
+			g.p("return %q", n.names[0])
+		case kindExtWildcard:
+			g.p("case %d: // %s", n.id, n.path())
+			g.p("return \"*.*\"")
+		}
+	}
+	g.p("}")
+	g.p("return \"\"")
+	g.p("}")
+	g.p("")
+}
+
+// finish writes the end of the matching at the nodes which are not routes.
+func (g *generator) finish() {
+	g.p("// finish returns the route of the node at the end of the path, or the")
+	g.p("// path to redirect to.")
+	g.p("func finish(node int, path string, params Params) (Route, Params, string) {")
+	g.p("if r := nodeRoutes[node]; r != NotFound {")
+	g.p("return r, params, \"\"")
+	g.p("}")
+	g.p("switch node {")
+	for _, n := range g.tree.nodes {
+		if n.route != nil {
+			continue
+		}
+		if _, ok := n.static[""]; ok {
+			g.p("case %d: // %s", n.id, n.path())
+			g.p("return NotFound, nil, path + \"/\"")
+		} else if len(n.options) > 0 {
+			g.p("case %d: // %s", n.id, n.path())
+			if r := n.options[0].route; r != nil {
+				g.p("return %s, params, \"\"", r.ident)
+			} else {
+				g.p("return NotFound, nil, \"\"")
+			}
+		}
+	}
+	g.p("}")
+	g.p("return NotFound, nil, \"\"")
+	g.p("}")
+}
+
+// builders writes the params struct, the params parser and the URL builder
+// of a named route.
+func (g *generator) builders(r *route) {
+	fs := fields(r)
+	g.p("")
+	if len(fs) > 0 {
+		g.p("// %sParams are the params of the route %q, %s.", r.ident, r.name, r.original)
+		g.p("type %sParams struct {", r.ident)
+		for _, f := range fs {
+			switch {
+			case f.isInt && f.optional:
+				g.p("%s *int", f.name)
+			case f.isInt:
+				g.p("%s int", f.name)
+			default:
+				g.p("%s string", f.name)
+			}
+		}
+		g.p("}")
+		g.p("")
+		g.p("// Parse%sParams returns the params of the route %q matched by Match.", r.ident, r.name)
+		g.p("func Parse%[1]sParams(params Params) (%[1]sParams, error) {", r.ident)
+		g.p("var p %sParams", r.ident)
+		ints := false
+		for _, f := range fs {
+			ints = ints || f.isInt && !f.optional
+		}
+		if ints {
+			g.p("var err error")
+		}
+		for _, f := range fs {
+			switch {
+			case f.isInt && f.optional:
+				g.p("if v := params.Get(%q); v != \"\" {", f.param)
+				g.p("n, err := strconv.Atoi(v)")
+				g.p("if err != nil {")
+				g.p("return p, err")
+				g.p("}")
+				g.p("p.%s = &n", f.name)
+				g.p("}")
+			case f.isInt:
+				g.p("if p.%s, err = strconv.Atoi(params.Get(%q)); err != nil {", f.name, f.param)
+				g.p("return p, err")
+				g.p("}")
+			default:
+				g.p("p.%s = params.Get(%q)", f.name, f.param)
+			}
+		}
+		g.p("return p, nil")
+		g.p("}")
+		g.p("")
+		g.p("// %sURL returns the escaped path of the route %q, as Node.BuildURL.", r.ident, r.name)
+		g.p("func %[1]sURL(p %[1]sParams) string {", r.ident)
+	} else {
+		g.p("// %sURL returns the path of the route %q.", r.ident, r.name)
+		g.p("func %sURL() string {", r.ident)
+	}
+	g.urlBody(r, fs)
+	g.p("}")
+}
+
+func (g *generator) urlBody(r *route, fs []field) {
+	byParam := make(map[string]field)
+	for _, f := range fs {
+		byParam[f.param] = f
+	}
+	value := func(param string) string {
+		f := byParam[param]
+		switch {
+		case f.isInt && f.optional:
+			return fmt.Sprintf("formatInt(p.%s)", f.name)
+		case f.isInt:
+			return fmt.Sprintf("strconv.Itoa(p.%s)", f.name)
+		case f.wildcard:
+			return fmt.Sprintf(`strings.Replace(pathEscape(p.%s), "%%2F", "/", -1)`, f.name)
+		}
+		return fmt.Sprintf("pathEscape(p.%s)", f.name)
+	}
+	set := func(param string) string {
+		if f := byParam[param]; f.isInt {
+			return fmt.Sprintf("p.%s != nil", f.name)
+		}
+		return fmt.Sprintf(`p.%s != ""`, byParam[param].name)
+	}
+	literal := func(s string) string {
+		return fmt.Sprintf("%q", pathEscape(s))
+	}
+
+	path := r.node.nodes()
+	// terms of the path, flushed before an optional segment
+	var terms []string
+	addLiteral := func(s string) {
+		if n := len(terms); n > 0 && strings.HasPrefix(terms[n-1], `"`) {
+			prev := terms[n-1]
+			terms[n-1] = fmt.Sprintf("%q", prev[1:len(prev)-1]+s)
+			return
+		}
+		terms = append(terms, fmt.Sprintf("%q", s))
+	}
+	started := false
+	flush := func() {
+		if len(terms) == 0 {
+			return
+		}
+		if started {
+			g.p("path += %s", strings.Join(terms, " + "))
+		} else {
+			g.p("path := %s", strings.Join(terms, " + "))
+			started = true
+		}
+		terms = nil
+	}
+	addLiteral("")
+	for _, n := range path {
+		var seg []string
+		var conds []string
+		switch n.kind {
+		case kindStatic:
+			seg = []string{literal(strings.Replace(n.segment, "::", ":", -1))}
+		case kindExtWildcard:
+			seg = []string{value(":path"), `"."`, value(":ext")}
+		case kindParam, kindOptional, kindWildcard:
+			seg = []string{value(n.names[0])}
+			if n.optional {
+				conds = append(conds, set(n.names[0]))
+			}
+		case kindRegexp:
+			for _, p := range n.parts {
+				if p.name == "" {
+					seg = append(seg, literal(p.literal))
+					continue
+				}
+				seg = append(seg, value(p.name))
+				if n.optional {
+					conds = append(conds, set(p.name))
+				}
+			}
+		}
+		if len(conds) > 0 {
+			flush()
+			if !started {
+				g.p("path := \"\"")
+				started = true
+			}
+			g.p("if %s {", strings.Join(conds, " || "))
+			g.p("path += \"/\" + %s", strings.Join(seg, " + "))
+			g.p("}")
+			continue
+		}
+		addLiteral("/")
+		for _, s := range seg {
+			if strings.HasPrefix(s, `"`) {
+				addLiteral(s[1 : len(s)-1])
+			} else {
+				terms = append(terms, s)
+			}
+		}
+	}
+	if !started {
+		g.p("return %s", strings.Join(terms, " + "))
+		return
+	}
+	flush()
+	g.p("return path")
+}
+
+// runtimeSource is the code shared by every generated matcher.
+const runtimeSource = `// Pattern returns the pattern of the route.
+func (r Route) Pattern() string {
+	return routePatterns[r]
+}
+
+// Name returns the name of the route, if any.
+func (r Route) Name() string {
+	return routeNames[r]
+}
+
+func (r Route) String() string {
+	return routePatterns[r]
+}
+
+// Allow returns the methods of the route, without "*".
+func (r Route) Allow() []string {
+	return routeAllow[r]
+}
+
+// Handles reports whether the route has a handler for the method, or for
+// all methods.
+func (r Route) Handles(method string) bool {
+	if routeAny[r] {
+		return true
+	}
+	for _, m := range routeAllow[r] {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Param is a param of a matched route.
+type Param struct {
+	Key, Value string
+}
+
+// Params are the params of a matched route, keys keep the leading colon.
+type Params []Param
+
+// Get returns the value of the param, empty if it is not set.
+func (ps Params) Get(key string) string {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+func (ps *Params) set(key, value string) {
+	for i, p := range *ps {
+		if p.Key == key {
+			(*ps)[i].Value = value
+			return
+		}
+	}
+	*ps = append(*ps, Param{Key: key, Value: value})
+}
+
+// Match matches the path as mux.Trie.Match with the default options: it
+// returns the route and its params, or NotFound and the path to redirect
+// to with or without trailing slash if any.
+func Match(path string) (Route, Params, string) {
+	if path == "" || path[0] != '/' {
+		return NotFound, nil, ""
+	}
+	for strings.Contains(path, "//") {
+		path = strings.Replace(path, "//", "/", -1)
+	}
+	var params Params
+	node, start := 0, 1
+	for start <= len(path) {
+		end := len(path)
+		if i := strings.IndexByte(path[start:], '/'); i >= 0 {
+			end = start + i
+		}
+		seg := path[start:end]
+		child := next(node, seg, path[end:])
+		redirect := ""
+		if child < 0 && end == len(path) {
+			if seg == "" && nodeRoutes[node] != NotFound {
+				redirect = path[:end-1]
+			}
+			for _, ext := range [...]string{".json", ".xml", ".html"} {
+				if strings.HasSuffix(seg, ext) {
+					if child = next(node, seg[:len(seg)-len(ext)], ""); child >= 0 {
+						seg = seg[:len(seg)-len(ext)]
+						params.set(":ext", ext[1:])
+						break
+					}
+				}
+			}
+		}
+		if child < 0 {
+			return NotFound, nil, redirect
+		}
+	found:
+		node = child
+		switch name := wildcard(node); name {
+		case "":
+			bind(node, seg, &params)
+		case "*.*":
+			v := path[start:]
+			dot := strings.IndexByte(v, '.')
+			params.set(":path", v[:dot])
+			params.set(":ext", v[dot+1:])
+			return finish(node, path, params)
+		default:
+			// extend the wildcard segment by segment until the next
+			// segment matches a child
+			for i := end; i < len(path); {
+				s, e := i+1, len(path)
+				if j := strings.IndexByte(path[s:], '/'); j >= 0 {
+					e = s + j
+				}
+				if c := next(node, path[s:e], path[e:]); c >= 0 {
+					params.set(name, path[start:i])
+					child, start, end, seg = c, s, e, path[s:e]
+					goto found
+				}
+				i = e
+			}
+			params.set(name, path[start:])
+			return finish(node, path, params)
+		}
+		start = end + 1
+	}
+	return finish(node, path, params)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// run returns the first run of the bytes of s in the class.
+func run(s string, in func(byte) bool) string {
+	i := 0
+	for i < len(s) && !in(s[i]) {
+		i++
+	}
+	j := i
+	for j < len(s) && in(s[j]) {
+		j++
+	}
+	return s[i:j]
+}
+
+// digits returns the leftmost match of [0-9]+ in s, "" if none.
+func digits(s string) string {
+	return run(s, isDigit)
+}
+
+// word returns the leftmost match of \w+ in s, "" if none.
+func word(s string) string {
+	return run(s, isWordByte)
+}
+
+// staticKey returns the segment with "::" replaced by ":", as the static
+// segments of the patterns.
+func staticKey(seg string) string {
+	if strings.Contains(seg, "::") {
+		return strings.Replace(seg, "::", ":", -1)
+	}
+	return seg
+}
+
+// wildcardSegment reports whether a wildcard can start with the segment.
+func wildcardSegment(seg string) bool {
+	return strings.Trim(seg, "\n") != ""
+}
+
+// extWildcard reports whether seg+rest has a non-empty path before its first
+// dot and an extension after it.
+func extWildcard(seg, rest string) bool {
+	if dot := strings.IndexByte(seg, '.'); dot >= 0 {
+		ext := seg[dot+1:]
+		return dot > 0 && len(ext)+len(rest) > 0 && !strings.Contains(ext, "\n") && !strings.Contains(rest, "\n")
+	}
+	dot := strings.IndexByte(rest, '.')
+	return dot >= 0 && len(seg)+dot > 0 && dot < len(rest)-1 && !strings.Contains(rest[dot+1:], "\n")
+}
+`
+
+// pathEscape escapes s as url.PathEscape, which needs Go 1.8: the bytes
+// other than letters, digits and -._~$&+:=@ are percent-encoded.
+func pathEscape(s string) string {
+	const hex = "0123456789ABCDEF"
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~$&+:=@", c) >= 0 {
+			b = append(b, c)
+		} else {
+			b = append(b, '%', hex[c>>4], hex[c&15])
+		}
+	}
+	return string(b)
+}
+
+// pathEscapeSource is pathEscape for the URL builders.
+const pathEscapeSource = `
+// pathEscape escapes s as url.PathEscape, which needs Go 1.8: the bytes
+// other than letters, digits and -._~$&+:=@ are percent-encoded.
+func pathEscape(s string) string {
+	const hex = "0123456789ABCDEF"
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~$&+:=@", c) >= 0 {
+			b = append(b, c)
+		} else {
+			b = append(b, '%', hex[c>>4], hex[c&15])
+		}
+	}
+	return string(b)
+}
+`
+
+// formatIntSource formats the optional int params of the URL builders.
+const formatIntSource = `
+// formatInt returns the decimal value of an optional int param, empty if
+// it is nil.
+func formatInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+`
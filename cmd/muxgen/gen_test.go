@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beego/mux"
+)
+
+// TestGenerateExample checks that the example is up to date, run go
+// generate in internal/example after changing the generator.
+func TestGenerateExample(t *testing.T) {
+	src, err := generateFile(filepath.Join("internal", "example", "routes.yaml"), "example", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile(filepath.Join("internal", "example", "routes.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, expected) {
+		t.Fatal("internal/example/routes.go is out of date, run go generate")
+	}
+}
+
+func generateRoutes(t *testing.T, routes string, brace bool) ([]byte, error) {
+	dir, err := ioutil.TempDir("", "muxgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "routes.yaml")
+	if err := ioutil.WriteFile(filename, []byte(routes), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return generateFile(filename, "routes", brace)
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := generateRoutes(t, `- method: GET
+  pattern: /users/{id:int}
+  handler: users.show
+  name: users.show
+- method: GET
+  pattern: /users/{id:int}/posts
+  handler: posts
+`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"// Code generated by muxgen from routes.yaml. DO NOT EDIT.",
+		"package routes",
+		"UsersShow:", "UsersIdPosts:",
+		`"/users/{id:int}"`,
+		"type UsersShowParams struct",
+		"func UsersShowURL(p UsersShowParams) string",
+	} {
+		if !bytes.Contains(src, []byte(s)) {
+			t.Fatalf("should generate %q", s)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	items := []struct {
+		routes, err string
+	}{
+		{"- method: GET\n  pattern: /a\n  handler: a\n- method: GET\n  pattern: /a\n  handler: b\n", `routes.yaml:4: GET "/a" already defined`},
+		{"- method: GET\n  pattern: /a\n  handler: a\n  unknown: b\n", `unknown field "unknown"`},
+		{"- method: GET\n  pattern: /a/:id([0-9]+\n  handler: a\n", "pattern"},
+		{"- method: GET\n  pattern: /a\n  handler: a\n  name: users\n- method: GET\n  pattern: /b\n  handler: b\n  name: usersURL\n",
+			"identifier UsersURL is already used"},
+	}
+	for _, v := range items {
+		if _, err := generateRoutes(t, v.routes, false); err == nil || !strings.Contains(err.Error(), v.err) {
+			t.Fatalf("%q: should fail with %q, got %v", v.routes, v.err, err)
+		}
+	}
+}
+
+func TestRouteIdent(t *testing.T) {
+	items := []struct {
+		pattern, ident string
+	}{
+		{"/", "Root"},
+		{"/users/:id:int", "UsersId"},
+		{"/users/?:page", "UsersPage"},
+		{"/files/*", "FilesSplat"},
+		{"/static/*filepath", "StaticFilepath"},
+		{"/assets/*.*", "AssetsPathExt"},
+		{"/cms_:id([0-9]+)_:slug.html", "IdSlug"},
+		{"/a::b", "AB"},
+		{"/2020", "R2020"},
+	}
+	for _, v := range items {
+		r := &route{endpoint: mux.NewTrie().Parse(v.pattern)}
+		newTree([]*route{r})
+		if id := routeIdent(r); id != v.ident {
+			t.Fatalf("%s: should be %s, got %s", v.pattern, v.ident, id)
+		}
+	}
+}
+
+func TestGenerateRegexps(t *testing.T) {
+	src, err := generateRoutes(t, `- method: GET
+  pattern: /users/:id:int
+  handler: users
+- method: GET
+  pattern: /tags/:tag:string
+  handler: tags
+- method: GET
+  pattern: /o/?:name:string
+  handler: o
+`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(src, []byte(`"regexp"`)) || bytes.Contains(src, []byte("MustCompile")) {
+		t.Fatalf("should match a single :int or :string param without regexp, got\n%s", src)
+	}
+
+	// other regexp segments are compiled as mux does
+	src, err = generateRoutes(t, "- method: GET\n  pattern: /cms_:id([0-9]+)_:slug.html\n  handler: cms\n", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(src, []byte("regexp.MustCompile(`cms_([0-9]+)_(.+).html`)")) {
+		t.Fatal("should compile the regexp of a custom regexp param")
+	}
+}
+
+func TestGenerateOptionalInt(t *testing.T) {
+	src, err := generateRoutes(t, `- method: GET
+  pattern: /o/?:major:int.:minor:int
+  handler: o
+  name: o
+`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"Major *int", "Minor *int", "p.Major = &n",
+		`if p.Major != nil || p.Minor != nil {`,
+		`path += "/" + formatInt(p.Major) + "." + formatInt(p.Minor)`,
+		"func formatInt(v *int) string",
+	} {
+		if !bytes.Contains(src, []byte(s)) {
+			t.Fatalf("should generate %q, got\n%s", s, src)
+		}
+	}
+}
+
+func TestGenerateBraceRegexp(t *testing.T) {
+	src, err := generateRoutes(t, `- method: GET
+  pattern: /lang/{lang:en|fr}
+  handler: lang
+  name: lang
+`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the param is the whole group (^(?:en|fr)$)
+	if s := "return \"/lang/\" + pathEscape(p.Lang)\n"; !bytes.Contains(src, []byte(s)) {
+		t.Fatalf("should generate %q", s)
+	}
+}
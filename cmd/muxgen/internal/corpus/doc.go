@@ -0,0 +1,7 @@
+// Package corpus is generated by muxgen from the routes of the match corpus
+// of mux, testdata/match.yaml. Its tests check the generated matcher
+// against mux.Trie on the paths of the corpus, as the reference matcher of
+// mux does.
+package corpus
+
+//go:generate go run ../../main.go ../../gen.go -pkg corpus -o routes.go ../../../../testdata/match.yaml
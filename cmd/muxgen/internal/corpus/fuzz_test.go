@@ -0,0 +1,16 @@
+//go:build go1.18
+// +build go1.18
+
+package corpus
+
+import "testing"
+
+func FuzzMatch(f *testing.F) {
+	tr, paths := loadCorpus(f)
+	for _, path := range paths {
+		f.Add(path)
+	}
+	f.Fuzz(func(t *testing.T, path string) {
+		checkMatch(t, tr, path)
+	})
+}
@@ -0,0 +1,104 @@
+package corpus
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/beego/mux"
+	"github.com/beego/mux/internal/routefile"
+)
+
+// testdata is the testdata directory of mux.
+var testdata = filepath.Join("..", "..", "..", "..", "testdata")
+
+// loadCorpus returns the trie of the routes of the match corpus, and its
+// paths with the FuzzMatch corpus of mux.
+func loadCorpus(t testing.TB) (*mux.Trie, []string) {
+	mx, _, err := routefile.Load(filepath.Join(testdata, "match.yaml"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(testdata, "match_paths.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	files, err := filepath.Glob(filepath.Join(testdata, "fuzz", "FuzzMatch", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		path, err := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(lines[len(lines)-1], "string("), ")"))
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		paths = append(paths, path)
+	}
+	return mx.Trie(), paths
+}
+
+func paramsMap(params Params) map[string]string {
+	m := make(map[string]string)
+	for _, p := range params {
+		m[p.Key] = p.Value
+	}
+	return m
+}
+
+// checkMatch checks that the generated matcher returns the same route,
+// params and redirect as the trie.
+func checkMatch(t testing.TB, tr *mux.Trie, path string) {
+	route, params, redirect := Match(path)
+	matched, err := tr.Match(path)
+	if err != nil {
+		if route != NotFound || redirect != "" {
+			t.Fatalf("%q: should not match, got %s %q", path, route.Pattern(), redirect)
+		}
+		return
+	}
+	pattern := ""
+	expected := map[string]string{}
+	if matched.Node != nil {
+		pattern = matched.Node.GetPattern()
+		for k, v := range matched.Params {
+			expected[k] = v
+		}
+	}
+	if route.Pattern() != pattern || redirect != matched.Path || !reflect.DeepEqual(paramsMap(params), expected) {
+		t.Fatalf("%q: should match %q %v %q, got %q %v %q",
+			path, pattern, expected, matched.Path, route.Pattern(), params, redirect)
+	}
+}
+
+// TestMatch checks the generated matcher on the paths of the corpus, and
+// random paths of their segments.
+func TestMatch(t *testing.T) {
+	tr, paths := loadCorpus(t)
+	var segments []string
+	for _, path := range paths {
+		segments = append(segments, strings.Split(path[1:], "/")...)
+	}
+	segments = append(segments, "\n", "1\n2", "a\n-b")
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		var b []string
+		for n := 1 + rnd.Intn(4); len(b) < n; {
+			b = append(b, segments[rnd.Intn(len(segments))])
+		}
+		paths = append(paths, "/"+strings.Join(b, "/"))
+	}
+	for _, path := range paths {
+		checkMatch(t, tr, path)
+	}
+}
@@ -0,0 +1,5 @@
+// Package example is generated by muxgen from routes.yaml, its tests check
+// the generated matcher against mux.Trie.
+package example
+
+//go:generate go run ../../main.go ../../gen.go -pkg example -o routes.go routes.yaml
@@ -0,0 +1,201 @@
+package example
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/beego/mux"
+	"github.com/beego/mux/internal/routefile"
+)
+
+func loadMux(t *testing.T) *mux.Mux {
+	mx, _, err := routefile.Load("routes.yaml", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mx
+}
+
+var segments = []string{
+	"", "users", "me", "posts", "repos", "articles", "files", "raw", "static", "assets", "data",
+	"list", "orgs", "tags", "docs", "ping", "v", "x", "a:b", "a::b", "12", "007", "abc", "a_b", "a-b", "a.b", "x.json",
+	"1.html", "cms_12_intro.html", "cms_x_intro.html", "2020-12", ":user", "*", "?:repo", "\n",
+}
+
+func randomPath(rnd *rand.Rand) string {
+	var b []string
+	for n := 1 + rnd.Intn(5); len(b) < n; {
+		b = append(b, segments[rnd.Intn(len(segments))])
+	}
+	return "/" + strings.Join(b, "/")
+}
+
+func paramsMap(params Params) map[string]string {
+	m := make(map[string]string)
+	for _, p := range params {
+		m[p.Key] = p.Value
+	}
+	return m
+}
+
+// TestMatch checks that the generated matcher returns the same routes,
+// params and redirects as the trie.
+func TestMatch(t *testing.T) {
+	tr := loadMux(t).Trie()
+	paths := []string{
+		"/", "/users", "/users/", "/users/12", "/users/me", "/users/bob", "/users/b-b", "/users/12/posts",
+		"/users/12/posts/3", "/users/12/posts/x", "/orgs/beego/repos", "/orgs/beego/repos/mux",
+		"/articles/cms_12_intro.html", "/articles/2020-12/", "/articles/2020-12", "/articles/tags/go",
+		"/files/a/b", "/files/a/b/raw", "/files/raw", "/static/css/app.css", "/assets/app.min.js",
+		"/assets/app", "/data/2012/11/12/list", "/a:b", "/a::b", "/docs", "/docs/", "/ping",
+		"/v/a/b", "/v/1/b", "/v/abc/x/y", "/x", "/x/12", "/x/abc", "/users/12.json", "//users", "users",
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		paths = append(paths, randomPath(rnd))
+	}
+	for _, path := range paths {
+		route, params, redirect := Match(path)
+		matched, err := tr.Match(path)
+		if err != nil {
+			if route != NotFound || redirect != "" {
+				t.Fatalf("%q: should not match, got %s %q", path, route.Pattern(), redirect)
+			}
+			continue
+		}
+		pattern := ""
+		expected := map[string]string{}
+		if matched.Node != nil {
+			pattern = matched.Node.GetPattern()
+			for k, v := range matched.Params {
+				expected[k] = v
+			}
+		}
+		if route.Pattern() != pattern || redirect != matched.Path || !reflect.DeepEqual(paramsMap(params), expected) {
+			t.Fatalf("%q: should match %q %v %q, got %q %v %q",
+				path, pattern, expected, matched.Path, route.Pattern(), params, redirect)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	if UsersShow.Pattern() != "/users/:id:int" || UsersShow.Name() != "users.show" ||
+		!reflect.DeepEqual(UsersShow.Allow(), []string{"GET", "PUT"}) {
+		t.Fatalf("should describe the route, got %q %q %v", UsersShow.Pattern(), UsersShow.Name(), UsersShow.Allow())
+	}
+	if !UsersShow.Handles("PUT") || UsersShow.Handles("DELETE") || !Ping.Handles("DELETE") || NotFound.Handles("GET") {
+		t.Fatal("should handle the methods of the routes")
+	}
+}
+
+// TestBuilders checks that the typed params parse the matched params and
+// build the same URL as Node.BuildURL.
+func TestBuilders(t *testing.T) {
+	mx := loadMux(t)
+	items := []struct {
+		path  string
+		route Route
+		build func(Params) (string, error)
+	}{
+		{"/", Home, func(Params) (string, error) { return HomeURL(), nil }},
+		{"/users", UsersList, func(Params) (string, error) { return UsersListURL(), nil }},
+		{"/users/42", UsersShow, func(ps Params) (string, error) {
+			p, err := ParseUsersShowParams(ps)
+			if p.Id != 42 {
+				t.Fatalf("should parse :id, got %d", p.Id)
+			}
+			return UsersShowURL(p), err
+		}},
+		{"/users/42/posts/3", PostsList, func(ps Params) (string, error) {
+			p, err := ParsePostsListParams(ps)
+			if p.Page == nil || *p.Page != 3 {
+				t.Fatalf("should parse :page, got %v", p.Page)
+			}
+			return PostsListURL(p), err
+		}},
+		{"/users/42/posts", PostsList, func(ps Params) (string, error) {
+			p, err := ParsePostsListParams(ps)
+			if p.Page != nil {
+				t.Fatalf("should parse a missing :page as nil, got %d", *p.Page)
+			}
+			return PostsListURL(p), err
+		}},
+		{"/orgs/beego/repos/mux", Repos, func(ps Params) (string, error) {
+			p, err := ParseReposParams(ps)
+			return ReposURL(p), err
+		}},
+		{"/articles/cms_12_intro.html", ArticlesShow, func(ps Params) (string, error) {
+			p, err := ParseArticlesShowParams(ps)
+			return ArticlesShowURL(p), err
+		}},
+		{"/articles/2020-12/", Archive, func(ps Params) (string, error) {
+			p, err := ParseArchiveParams(ps)
+			if p.Year != 2020 || p.Month != 12 {
+				t.Fatalf("should parse :year and :month, got %+v", p)
+			}
+			return ArchiveURL(p), err
+		}},
+		{"/files/a/b/raw", FilesRaw, func(ps Params) (string, error) {
+			p, err := ParseFilesRawParams(ps)
+			return FilesRawURL(p), err
+		}},
+		{"/static/css/app.css", Static, func(ps Params) (string, error) {
+			p, err := ParseStaticParams(ps)
+			return StaticURL(p), err
+		}},
+		{"/assets/js/app.min.js", Assets, func(ps Params) (string, error) {
+			p, err := ParseAssetsParams(ps)
+			return AssetsURL(p), err
+		}},
+		{"/ping", Ping, func(Params) (string, error) { return PingURL(), nil }},
+	}
+	for _, v := range items {
+		route, params, _ := Match(v.path)
+		if route != v.route {
+			t.Fatalf("%s: should match %s, got %s", v.path, v.route, route)
+		}
+		path, err := v.build(params)
+		if err != nil {
+			t.Fatalf("%s: should parse params %v, got %v", v.path, params, err)
+		}
+		var pairs []string
+		for _, p := range params {
+			pairs = append(pairs, p.Key, p.Value)
+		}
+		u, err := mx.Trie().Parse("/").GetName(route.Name()).BuildURL(pairs...)
+		if err != nil || path != u.String() {
+			t.Fatalf("%s: should build %q, got %q %v", v.path, u, path, err)
+		}
+		if again, _, _ := Match(path); again != route {
+			t.Fatalf("%s: built %q which should match %s, got %s", v.path, path, route, again)
+		}
+	}
+	if _, err := ParseUsersShowParams(Params{{Key: ":id", Value: "99999999999999999999"}}); err == nil {
+		t.Fatal("should fail to parse an int overflow")
+	}
+}
+
+// TestBuildersEscape checks that the URL builders escape the params as
+// Node.BuildURL.
+func TestBuildersEscape(t *testing.T) {
+	mx := loadMux(t)
+	items := []struct {
+		route Route
+		path  string
+		pairs []string
+	}{
+		{Repos, ReposURL(ReposParams{Org: "x?y z", Repo: "a#b%"}), []string{":org", "x?y z", ":repo", "a#b%"}},
+		{ArticlesShow, ArticlesShowURL(ArticlesShowParams{Id: "1 2", Slug: "x?y"}), []string{":id", "1 2", ":slug", "x?y"}},
+		{FilesRaw, FilesRawURL(FilesRawParams{Splat: "a b/c?d"}), []string{":splat", "a b/c?d"}},
+		{Static, StaticURL(StaticParams{Filepath: "css/a b.css"}), []string{":filepath", "css/a b.css"}},
+		{Assets, AssetsURL(AssetsParams{Path: "js/a?b", Ext: "min js"}), []string{":path", "js/a?b", ":ext", "min js"}},
+	}
+	for _, v := range items {
+		u, err := mx.Trie().Parse("/").GetName(v.route.Name()).BuildURL(v.pairs...)
+		if err != nil || v.path != u.String() {
+			t.Fatalf("%s: should build %q, got %q %v", v.route.Name(), u, v.path, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Command muxgen generates a Go matcher for a route table file, to match
+// paths without building a mux.Trie at startup.
+//
+//  muxgen [-pkg routes] [-o routes.go] [-brace] routes.yaml
+//
+// The route table file is read with mux.ParseRoutes and checked by loading
+// it in a Mux, disabled routes are skipped. The generated package has:
+//
+//  - a Route constant per pattern, named after the route name if any
+//  - Match(path) returning the Route, its Params, or the path to redirect
+//    to, as Trie.Match with the default options: case sensitive and
+//    cleaned paths
+//  - per named route, a params struct, with int fields for :int params and
+//    *int fields for optional :int params, nil when omitted, its parser
+//    from Params and a URL builder, which escapes the params as
+//    url.PathEscape and only keeps the slashes of wildcards
+//
+// The matcher switches on node ids and segments: it has no map lookup and
+// only uses regexps for the segments with literals, several params or
+// custom regexp params, e.g. cms_:id([0-9]+).html, not for the segments of
+// a single :int, :string or plain param.
+//
+//  //go:generate muxgen -pkg routes -o routes.go routes.yaml
+//
+//  route, params, redirect := routes.Match(r.URL.Path)
+//  switch route {
+//  case routes.UsersShow:
+//  	p, err := routes.ParseUsersShowParams(params)
+//  	...
+//  }
+//  http.Redirect(w, r, routes.UsersShowURL(routes.UsersShowParams{Id: 42}), http.StatusFound)
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/beego/mux"
+	"github.com/beego/mux/internal/routefile"
+)
+
+func main() {
+	var (
+		pkg    = flag.String("pkg", "routes", "package name of the generated file")
+		output = flag.String("o", "", "output file, the standard output if empty")
+		brace  = flag.Bool("brace", false, "read the patterns in brace syntax")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: muxgen [flags] routes.json|routes.yaml\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := generateFile(flag.Arg(0), *pkg, *brace)
+	if err == nil {
+		if *output == "" {
+			_, err = os.Stdout.Write(src)
+		} else {
+			err = ioutil.WriteFile(*output, src, 0644)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "muxgen:", err)
+		os.Exit(1)
+	}
+}
+
+// generateFile returns the generated source of the route table file.
+func generateFile(filename, pkg string, brace bool) ([]byte, error) {
+	routes, err := loadRoutes(filename, brace)
+	if err != nil {
+		return nil, err
+	}
+	return generate(pkg, filepath.Base(filename), routes)
+}
+
+// loadRoutes loads the route table file in a Mux with placeholder handlers
+// and middleware, and returns its patterns in adding order.
+func loadRoutes(filename string, brace bool) ([]*route, error) {
+	mx, configs, err := routefile.Load(filename, brace)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []*route
+	byNode := make(map[*mux.Node]*route)
+	for _, c := range configs {
+		if c.Disabled {
+			continue
+		}
+		node := mx.Trie().Parse(c.Pattern)
+		r, ok := byNode[node]
+		if !ok {
+			r = &route{
+				endpoint: node,
+				original: node.GetPattern(),
+				name:     node.GetRouteName(),
+				methods:  node.GetAllow(),
+				priority: node.GetPriority(),
+			}
+			byNode[node] = r
+			routes = append(routes, r)
+		}
+		r.any = r.any || c.Method == mux.MethodAny
+	}
+	return routes, nil
+}
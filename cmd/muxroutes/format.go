@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/beego/mux"
+)
+
+// write writes the routes in the format.
+func write(w io.Writer, format string, routes []mux.RouteInfo) error {
+	switch format {
+	case "table":
+		return writeTable(w, routes)
+	case "json":
+		return writeJSON(w, routes)
+	case "markdown", "md":
+		return writeMarkdown(w, routes)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func writeTable(w io.Writer, routes []mux.RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tPARAMS\tKIND")
+	for _, r := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Pattern, dash(r.Name), dash(formatParams(r.Params)), r.Kind)
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, routes []mux.RouteInfo) error {
+	if routes == nil {
+		routes = []mux.RouteInfo{}
+	}
+	b, err := json.MarshalIndent(routes, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func writeMarkdown(w io.Writer, routes []mux.RouteInfo) error {
+	var b bytes.Buffer
+	b.WriteString("| Method | Pattern | Name | Params | Kind |\n")
+	b.WriteString("|--------|---------|------|--------|------|\n")
+	for _, r := range routes {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			r.Method, code(r.Pattern), code(r.Name), code(formatParams(r.Params)), r.Kind)
+	}
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// formatParams formats the params in pattern syntax, e.g. ":id:int, :path*".
+func formatParams(params []mux.ParamInfo) string {
+	s := make([]string, len(params))
+	for i, p := range params {
+		name := p.Name
+		if p.Optional {
+			name = "?" + name
+		}
+		switch p.Type {
+		case "int", "string":
+			s[i] = name + ":" + p.Type
+		case "regexp":
+			s[i] = name + "(" + p.Regexp + ")"
+		case "wildcard":
+			s[i] = name + "*"
+		default:
+			s[i] = name
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// code formats a Markdown code span, with the pipes escaped for tables.
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.Replace(s, "|", `\|`, -1) + "`"
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+	return s
+}
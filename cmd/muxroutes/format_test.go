@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/beego/mux"
+)
+
+const testRoutes = `- method: GET
+  pattern: /users
+  handler: users.list
+- method: GET
+  pattern: /users/:id:int
+  handler: users.show
+  name: users.show
+  middleware: [auth]
+- method: GET
+  pattern: /files/*filepath
+  handler: files
+- method: GET
+  pattern: /a|b/:x(a|b)
+  handler: pipes
+- method: DELETE
+  pattern: /users/:id:int
+  handler: users.delete
+  disabled: true
+`
+
+func loadTestRoutes(t *testing.T) []mux.RouteInfo {
+	dir, err := ioutil.TempDir("", "muxroutes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "routes.yaml")
+	if err := ioutil.WriteFile(filename, []byte(testRoutes), 0644); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := routesFromFile(filename, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return routes
+}
+
+func TestWrite(t *testing.T) {
+	routes := loadTestRoutes(t)
+	items := []struct {
+		format, expected string
+	}{
+		{"table", `METHOD  PATTERN           NAME        PARAMS      KIND
+GET     /a|b/:x(a|b)      -           :x(a|b)     regexp
+GET     /files/*filepath  -           :filepath*  wildcard
+GET     /users            -           -           static
+GET     /users/:id:int    users.show  :id:int     regexp
+`},
+		{"markdown", "| Method | Pattern | Name | Params | Kind |\n" +
+			"|--------|---------|------|--------|------|\n" +
+			"| GET | `/a\\|b/:x(a\\|b)` |  | `:x(a\\|b)` | regexp |\n" +
+			"| GET | `/files/*filepath` |  | `:filepath*` | wildcard |\n" +
+			"| GET | `/users` |  |  | static |\n" +
+			"| GET | `/users/:id:int` | `users.show` | `:id:int` | regexp |\n"},
+	}
+	for _, v := range items {
+		var b bytes.Buffer
+		if err := write(&b, v.format, routes); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != v.expected {
+			t.Fatalf("%s: should write\n%s\ngot\n%s", v.format, v.expected, b.String())
+		}
+	}
+
+	var b bytes.Buffer
+	if err := write(&b, "json", routes[3:4]); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[
+  {
+    "method": "GET",
+    "pattern": "/users/:id:int",
+    "name": "users.show",
+    "params": [
+      {
+        "name": ":id",
+        "type": "int"
+      }
+    ],
+    "kind": "regexp"
+  }
+]
+`
+	if b.String() != expected {
+		t.Fatalf("json: should write\n%s\ngot\n%s", expected, b.String())
+	}
+	if err := write(&b, "xml", routes); err == nil {
+		t.Fatal("should fail for an unknown format")
+	}
+}
+
+func TestSelectRoutes(t *testing.T) {
+	routes := map[string][]mux.RouteInfo{
+		"api":   {{Method: "GET", Pattern: "/api"}},
+		"admin": {{Method: "GET", Pattern: "/admin"}},
+	}
+	if r, err := selectRoutes(routes, "example.com/server", "api"); err != nil || r[0].Pattern != "/api" {
+		t.Fatalf("should select api, got %v %v", r, err)
+	}
+	if _, err := selectRoutes(routes, "example.com/server", ""); err == nil ||
+		err.Error() != "several Mux registered in example.com/server, choose one with -name: admin, api" {
+		t.Fatalf("should ask for a name, got %v", err)
+	}
+	delete(routes, "admin")
+	if r, err := selectRoutes(routes, "example.com/server", ""); err != nil || r[0].Pattern != "/api" {
+		t.Fatalf("should select the only Mux, got %v %v", r, err)
+	}
+	if _, err := selectRoutes(routes, "example.com/server", "admin"); err == nil {
+		t.Fatal("should fail for a name not registered")
+	}
+}
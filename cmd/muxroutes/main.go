@@ -0,0 +1,168 @@
+// Command muxroutes prints the route table of a mux.Mux, from a route table
+// file or from a Go package registering a Mux with mux.Register.
+//
+//  muxroutes [-format table|json|markdown] [-brace] routes.yaml
+//  muxroutes [-format table|json|markdown] [-name api] -pkg ./server
+//
+// A route table file is read with mux.ParseRoutes, JSON if its name ends with
+// ".json", otherwise YAML-like. Disabled routes are not printed.
+//
+// With -pkg, a main package importing the package is written to a temporary
+// directory and run with "go run" from the current directory, so the package
+// and github.com/beego/mux must be importable from there, by the module or
+// the GOPATH of the current directory. The package registers the function
+// building its Mux in an init function:
+//
+//  func init() {
+//  	mux.Register("api", NewRouter)
+//  }
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/beego/mux"
+	"github.com/beego/mux/internal/routefile"
+)
+
+func main() {
+	var (
+		format = flag.String("format", "table", "output format: table, json or markdown")
+		brace  = flag.Bool("brace", false, "read the patterns of a route table file in brace syntax")
+		pkg    = flag.String("pkg", "", "Go package registering the Mux with mux.Register")
+		name   = flag.String("name", "", "name of the registered Mux, if the package registers several")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: muxroutes [flags] routes.json|routes.yaml\n       muxroutes [flags] -pkg package\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var (
+		routes []mux.RouteInfo
+		err    error
+	)
+	switch {
+	case *pkg != "" && flag.NArg() == 0:
+		routes, err = routesFromPackage(*pkg, *name)
+	case *pkg == "" && flag.NArg() == 1:
+		routes, err = routesFromFile(flag.Arg(0), *brace)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err == nil {
+		err = write(os.Stdout, *format, routes)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "muxroutes:", err)
+		os.Exit(1)
+	}
+}
+
+// routesFromFile loads a route table file in a Mux with placeholder
+// handlers and middleware.
+func routesFromFile(filename string, brace bool) ([]mux.RouteInfo, error) {
+	mx, _, err := routefile.Load(filename, brace)
+	if err != nil {
+		return nil, err
+	}
+	return mx.Routes(), nil
+}
+
+const mainSource = `package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/beego/mux"
+	_ %q
+)
+
+func main() {
+	routes := make(map[string][]mux.RouteInfo)
+	for _, name := range mux.RegisteredNames() {
+		routes[name] = mux.Registered(name).Routes()
+	}
+	json.NewEncoder(os.Stdout).Encode(routes)
+}
+`
+
+// routesFromPackage runs a temporary main package importing pkg, which
+// prints the routes of the registered Mux as JSON.
+func routesFromPackage(pkg, name string) ([]mux.RouteInfo, error) {
+	out, err := command("go", "list", "-f", "{{.ImportPath}}", pkg)
+	if err != nil {
+		return nil, err
+	}
+	importPath := strings.TrimSpace(string(out))
+
+	// the file is run from the current directory, which is left untouched
+	dir, err := ioutil.TempDir("", "muxroutes-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(file, []byte(fmt.Sprintf(mainSource, importPath)), 0644); err != nil {
+		return nil, err
+	}
+	if out, err = command("go", "run", file); err != nil {
+		return nil, err
+	}
+
+	var routes map[string][]mux.RouteInfo
+	if err := json.Unmarshal(out, &routes); err != nil {
+		return nil, err
+	}
+	return selectRoutes(routes, importPath, name)
+}
+
+// selectRoutes returns the routes of the Mux registered with the name,
+// which can be omitted if there is only one.
+func selectRoutes(routes map[string][]mux.RouteInfo, pkg, name string) ([]mux.RouteInfo, error) {
+	if name != "" {
+		r, ok := routes[name]
+		if !ok {
+			return nil, fmt.Errorf("no Mux registered as %q in %s", name, pkg)
+		}
+		return r, nil
+	}
+	switch len(routes) {
+	case 0:
+		return nil, fmt.Errorf("no Mux registered in %s, call mux.Register in an init function", pkg)
+	case 1:
+		for _, r := range routes {
+			return r, nil
+		}
+	}
+	names := make([]string, 0, len(routes))
+	for n := range routes {
+		names = append(names, n)
+	}
+	return nil, fmt.Errorf("several Mux registered in %s, choose one with -name: %s", pkg, strings.Join(sortedStrings(names), ", "))
+}
+
+func command(name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,559 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RouteConfig describes a route of a route table file.
+type RouteConfig struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+
+	// Handler is the name of the handler in the Registry.
+	Handler string `json:"handler"`
+
+	// Name is the route name, if any.
+	Name string `json:"name,omitempty"`
+
+	// Middleware are the names of the middleware in the Registry wrapping
+	// the handler, the first one is the outermost.
+	Middleware []string `json:"middleware,omitempty"`
+
+	// Metadata is set on the route of the method, see Route.Metadata.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Priority is the priority of the route, see Node.Priority.
+	Priority int `json:"priority,omitempty"`
+
+	// Disabled routes are not registered.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// File and Line are the position of the route in the route table file.
+	File string `json:"-"`
+	Line int    `json:"-"`
+}
+
+// Middleware wraps a handler.
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Registry holds the handlers and middleware a route table refers to by name.
+type Registry struct {
+	Handlers   map[string]http.HandlerFunc
+	Middleware map[string]Middleware
+}
+
+// ConfigError is an error of a route table at a position.
+type ConfigError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s", e.File, e.Err)
+	}
+	return e.Err.Error()
+}
+
+// ConfigErrors are all the errors found in a route table.
+type ConfigErrors []*ConfigError
+
+func (e ConfigErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+func (e ConfigErrors) add(route RouteConfig, format string, args ...interface{}) ConfigErrors {
+	return append(e, &ConfigError{File: route.File, Line: route.Line, Err: fmt.Errorf(format, args...)})
+}
+
+// LoadRoutesFile reads a route table file with ParseRoutes and registers
+// its routes with LoadRoutes.
+func (m *Mux) LoadRoutesFile(filename string, registry Registry) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	routes, err := ParseRoutes(f, filename)
+	if err != nil {
+		return err
+	}
+	return m.LoadRoutes(routes, registry)
+}
+
+// LoadRoutes registers the enabled routes in the Mux, in order.
+// All routes are checked before registering any of them, the returned
+// ConfigErrors holds every invalid method or pattern, unknown handler or
+// middleware, duplicate route or name. The routes are then registered in a
+// scratch copy of the trie, so that the errors found while registering, such
+// as conflicts under the ConflictError policy, leave the Mux untouched too.
+func (m *Mux) LoadRoutes(routes []RouteConfig, registry Registry) error {
+	var errs ConfigErrors
+	seen := make(map[string]bool)
+	// the pattern as registered and in colon syntax by route name
+	names := make(map[string][2]string)
+	for _, route := range routes {
+		if route.Disabled {
+			continue
+		}
+		method := strings.ToUpper(route.Method)
+		if !isMethod(method) {
+			errs = errs.add(route, "invalid method %q", route.Method)
+		}
+		if _, ok := registry.Handlers[route.Handler]; !ok {
+			errs = errs.add(route, "handler %q is not registered", route.Handler)
+		}
+		for _, name := range route.Middleware {
+			if _, ok := registry.Middleware[name]; !ok {
+				errs = errs.add(route, "middleware %q is not registered", name)
+			}
+		}
+		pattern, spans, err := m.trie.normalize(route.Pattern)
+		if err == nil {
+			if err = validatePattern(pattern); err != nil {
+				if e, ok := err.(*ErrInvalidPattern); ok {
+					e.Pattern, e.Column = route.Pattern, braceColumn(spans, e.Column)
+				}
+			}
+		}
+		if err != nil {
+			errs = errs.add(route, "%s", err)
+			continue
+		}
+		key := pattern
+		if !m.trie.caseSensitive {
+			key = strings.ToLower(key)
+		}
+		if node := m.trie.lookup(route.Pattern); seen[method+" "+key] || node != nil && node.handlers[method] != nil {
+			errs = errs.add(route, "%s %q already defined", method, route.Pattern)
+		}
+		seen[method+" "+key] = true
+		if route.Name != "" {
+			owner, ok := names[route.Name]
+			if !ok {
+				if node := m.trie.root.GetName(route.Name); node != nil {
+					owner, ok = [2]string{node.pattern, node.colonPattern}, true
+				}
+			}
+			if ok && owner[1] != pattern {
+				errs = errs.add(route, "route name %q already used by %q", route.Name, owner[0])
+			}
+			names[route.Name] = [2]string{route.Pattern, pattern}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	handlers := make([]http.HandlerFunc, len(routes))
+	for i, route := range routes {
+		if route.Disabled {
+			continue
+		}
+		handler := registry.Handlers[route.Handler]
+		for j := len(route.Middleware) - 1; j >= 0; j-- {
+			handler = registry.Middleware[route.Middleware[j]](handler)
+		}
+		handlers[i] = handler
+	}
+	// register in a scratch trie first, a conflict midway under the
+	// ConflictError policy leaves the Mux untouched
+	scratch := &Mux{trie: m.trie.scratch()}
+	if errs := scratch.loadRoutes(routes, handlers); len(errs) > 0 {
+		return errs
+	}
+	if errs := m.loadRoutes(routes, handlers); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// loadRoutes registers the enabled routes with their handlers.
+func (m *Mux) loadRoutes(routes []RouteConfig, handlers []http.HandlerFunc) ConfigErrors {
+	var errs ConfigErrors
+	for i, route := range routes {
+		if route.Disabled {
+			continue
+		}
+		node, err := m.TryHandle(route.Method, route.Pattern, handlers[i])
+		if err != nil {
+			errs = errs.add(route, "%s", err)
+			continue
+		}
+		if route.Name != "" && node.routeName != route.Name {
+			if _, err := node.TryName(route.Name); err != nil {
+				errs = errs.add(route, "%s", err)
+			}
+		}
+		if route.Priority != 0 {
+			node.Priority(route.Priority)
+		}
+		for k, v := range route.Metadata {
+			node.metaMethod(strings.ToUpper(route.Method), k, v)
+		}
+	}
+	return errs
+}
+
+// scratch returns a trie with the options and the routes of t, whose
+// handlers are placeholders, to try registering routes without touching t.
+func (t *Trie) scratch() *Trie {
+	s := *t
+	s.onConflict = func(*Conflict) {}
+	s.root = NewTrie().root
+	// the routes of t were accepted in their adding order
+	s.conflictPolicy = ConflictAllow
+	t.root.walk(func(n *Node) {
+		methods := n.methods()
+		if len(methods) == 0 && n.routeName == "" {
+			return
+		}
+		node, err := s.TryParse(n.pattern)
+		if err != nil {
+			return
+		}
+		for _, method := range methods {
+			node.TryHandle(method, true)
+		}
+		if n.routeName != "" {
+			node.TryName(n.routeName)
+		}
+		node.Priority(n.priority)
+	})
+	s.conflictPolicy = t.conflictPolicy
+	return &s
+}
+
+// ParseRoutes reads a route table, in JSON if the file name ends with
+// ".json", otherwise in the YAML-like format below. The returned error is
+// a ConfigErrors holding all the errors with their line.
+//
+// A JSON route table is an array of objects with the fields of RouteConfig:
+//
+//  [
+//    {"method": "GET", "pattern": "/users/:id", "handler": "users.show",
+//     "middleware": ["auth"], "metadata": {"team": "accounts"}}
+//  ]
+//
+// The YAML-like format is a list of routes with scalar values, inline or
+// block lists and a metadata map, "#" starts a comment:
+//
+//  - method: GET
+//    pattern: /users/:id
+//    handler: users.show
+//    name: users.show
+//    middleware: [auth, log]
+//    metadata:
+//      team: accounts
+//  - method: DELETE
+//    pattern: /users/:id
+//    handler: users.delete
+//    disabled: true
+func ParseRoutes(r io.Reader, filename string) ([]RouteConfig, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return parseJSONRoutes(data, filename)
+	}
+	return parseYAMLRoutes(data, filename)
+}
+
+func parseJSONRoutes(data []byte, filename string) ([]RouteConfig, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		line := 0
+		if e, ok := err.(*json.SyntaxError); ok {
+			line = lineAt(data, int(e.Offset))
+		}
+		return nil, ConfigErrors{{File: filename, Line: line, Err: err}}
+	}
+
+	var (
+		routes []RouteConfig
+		errs   ConfigErrors
+	)
+	offsets := jsonElementOffsets(data)
+	for i, msg := range raw {
+		route := RouteConfig{File: filename, Line: lineAt(data, offsets[i])}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(msg, &fields); err != nil {
+			errs = errs.add(route, "route should be an object")
+			continue
+		}
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := fields[k]
+			var err error
+			switch k {
+			case "method":
+				err = json.Unmarshal(v, &route.Method)
+			case "pattern":
+				err = json.Unmarshal(v, &route.Pattern)
+			case "handler":
+				err = json.Unmarshal(v, &route.Handler)
+			case "name":
+				err = json.Unmarshal(v, &route.Name)
+			case "middleware":
+				err = json.Unmarshal(v, &route.Middleware)
+			case "metadata":
+				err = json.Unmarshal(v, &route.Metadata)
+			case "priority":
+				err = json.Unmarshal(v, &route.Priority)
+			case "disabled":
+				err = json.Unmarshal(v, &route.Disabled)
+			default:
+				err = fmt.Errorf("unknown field %q", k)
+			}
+			if _, ok := err.(*json.UnmarshalTypeError); ok {
+				err = fmt.Errorf("invalid %s: %s", k, v)
+			}
+			if err != nil {
+				errs = errs.add(route, "%s", err)
+			}
+		}
+		routes = append(routes, route)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return routes, nil
+}
+
+// jsonElementOffsets returns the offsets of the elements of a valid JSON array.
+func jsonElementOffsets(data []byte) []int {
+	var (
+		offsets  []int
+		depth    int
+		inString bool
+		escaped  bool
+		expected bool
+	)
+	for i, c := range data {
+		switch {
+		case inString:
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			continue
+		}
+		if expected && c != ']' {
+			offsets = append(offsets, i)
+		}
+		expected = false
+		switch c {
+		case '"':
+			inString = true
+		case '[', '{':
+			depth++
+			expected = depth == 1
+		case ']', '}':
+			depth--
+		case ',':
+			expected = depth == 1
+		}
+	}
+	return offsets
+}
+
+// lineAt returns the 1-based line of the offset.
+func lineAt(data []byte, offset int) int {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+func parseYAMLRoutes(data []byte, filename string) ([]RouteConfig, error) {
+	var (
+		routes []RouteConfig
+		errs   ConfigErrors
+		route  *RouteConfig
+		// nested is the field holding a block of the lines indented more
+		// than nestedIndent, "metadata" or "middleware".
+		nested       string
+		nestedIndent int
+	)
+	for i, line := range strings.Split(string(data), "\n") {
+		pos := RouteConfig{File: filename, Line: i + 1}
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" {
+			continue
+		}
+		if strings.HasPrefix(content, "\t") {
+			errs = errs.add(pos, "tabs are not allowed for indentation")
+			continue
+		}
+		indent := len(line) - len(content)
+
+		if nested != "" && indent > nestedIndent {
+			switch nested {
+			case "middleware":
+				if !strings.HasPrefix(content, "- ") {
+					errs = errs.add(pos, "middleware item should start with \"- \"")
+					continue
+				}
+				route.Middleware = append(route.Middleware, unquote(strings.TrimSpace(content[2:])))
+			case "metadata":
+				k, v, ok := splitKeyValue(content)
+				if !ok {
+					errs = errs.add(pos, "metadata should be \"key: value\"")
+					continue
+				}
+				if route.Metadata == nil {
+					route.Metadata = make(map[string]string)
+				}
+				route.Metadata[k] = unquote(v)
+			}
+			continue
+		}
+		nested = ""
+
+		if indent == 0 {
+			if content != "-" && !strings.HasPrefix(content, "- ") {
+				errs = errs.add(pos, "route should start with \"- \"")
+				route = nil
+				continue
+			}
+			routes = append(routes, pos)
+			route = &routes[len(routes)-1]
+			content = strings.TrimLeft(content[1:], " ")
+			indent = len(line) - len(content)
+			if content == "" {
+				continue
+			}
+		}
+		if route == nil {
+			errs = errs.add(pos, "field outside of a route")
+			continue
+		}
+		k, v, ok := splitKeyValue(content)
+		if !ok {
+			errs = errs.add(pos, "field should be \"key: value\"")
+			continue
+		}
+		s := unquote(v)
+		switch k {
+		case "method":
+			route.Method = s
+		case "pattern":
+			route.Pattern = s
+		case "handler":
+			route.Handler = s
+		case "name":
+			route.Name = s
+		case "middleware":
+			switch {
+			case v == "":
+				nested, nestedIndent = k, indent
+			case strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]"):
+				for _, item := range strings.Split(v[1:len(v)-1], ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						route.Middleware = append(route.Middleware, unquote(item))
+					}
+				}
+			default:
+				route.Middleware = append(route.Middleware, s)
+			}
+		case "metadata":
+			if v != "" {
+				errs = errs.add(pos, "metadata should be a block of \"key: value\" lines")
+				continue
+			}
+			nested, nestedIndent = k, indent
+		case "priority":
+			p, err := strconv.Atoi(s)
+			if err != nil {
+				errs = errs.add(pos, "invalid priority %q", v)
+			}
+			route.Priority = p
+		case "disabled":
+			d, err := strconv.ParseBool(s)
+			if err != nil {
+				errs = errs.add(pos, "invalid disabled %q", v)
+			}
+			route.Disabled = d
+		default:
+			errs = errs.add(pos, "unknown field %q", k)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return routes, nil
+}
+
+// stripComment removes a comment starting with "#" at the beginning of the
+// line or after a space, outside of quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func splitKeyValue(s string) (key, value string, ok bool) {
+	i := strings.Index(s, ":")
+	if i <= 0 || i+1 < len(s) && s[i+1] != ' ' {
+		return "", "", false
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), true
+}
+
+func unquote(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	switch {
+	case s[0] == '"' && s[len(s)-1] == '"':
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	case s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1)
+	}
+	return s
+}
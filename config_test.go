@@ -0,0 +1,236 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testYAMLRoutes = `# users
+- method: GET
+  pattern: /users/:id
+  handler: users.show
+  name: users.show
+  middleware: [auth, "log"]
+  metadata:
+    team: accounts # owner
+    doc: 'https://example.com/#users'
+- method: delete
+  pattern: /users/:id
+  handler: users.delete
+  middleware:
+    - auth
+  priority: 5
+-
+  method: GET
+  pattern: /admin
+  handler: missing
+  disabled: true
+`
+
+const testJSONRoutes = `[
+  {"method": "GET", "pattern": "/users/:id", "handler": "users.show", "name": "users.show",
+   "middleware": ["auth", "log"], "metadata": {"team": "accounts", "doc": "https://example.com/#users"}},
+  {
+    "method": "delete",
+    "pattern": "/users/:id",
+    "handler": "users.delete",
+    "middleware": ["auth"],
+    "priority": 5
+  },
+  {"method": "GET", "pattern": "/admin", "handler": "missing", "disabled": true}
+]`
+
+func TestParseRoutes(t *testing.T) {
+	for _, filename := range []string{"routes.yaml", "routes.json"} {
+		src := testYAMLRoutes
+		lines := []int{2, 10, 16}
+		if strings.HasSuffix(filename, ".json") {
+			src = testJSONRoutes
+			lines = []int{2, 4, 11}
+		}
+		routes, err := ParseRoutes(strings.NewReader(src), filename)
+		if err != nil {
+			t.Fatalf("%s: should parse, got %v", filename, err)
+		}
+		if len(routes) != 3 {
+			t.Fatalf("%s: should parse 3 routes, got %d", filename, len(routes))
+		}
+		r := routes[0]
+		if r.Method != "GET" || r.Pattern != "/users/:id" || r.Handler != "users.show" || r.Name != "users.show" ||
+			strings.Join(r.Middleware, ",") != "auth,log" || r.Metadata["team"] != "accounts" ||
+			r.Metadata["doc"] != "https://example.com/#users" {
+			t.Fatalf("%s: wrong first route %+v", filename, r)
+		}
+		r = routes[1]
+		if r.Method != "delete" || r.Handler != "users.delete" || strings.Join(r.Middleware, ",") != "auth" || r.Priority != 5 {
+			t.Fatalf("%s: wrong second route %+v", filename, r)
+		}
+		if r = routes[2]; !r.Disabled || r.Pattern != "/admin" {
+			t.Fatalf("%s: wrong third route %+v", filename, r)
+		}
+		for i, r := range routes {
+			if r.File != filename || r.Line != lines[i] {
+				t.Fatalf("%s: route %d should be at line %d, got %s:%d", filename, i, lines[i], r.File, r.Line)
+			}
+		}
+	}
+}
+
+func TestParseRoutesErrors(t *testing.T) {
+	items := []struct {
+		filename, src string
+		errs          []string
+	}{
+		{"routes.yaml", "method: GET\n", []string{"routes.yaml:1: route should start with \"- \""}},
+		{"routes.yaml", "- method: GET\n  priority: high\n  colour: red\n  metadata: x\n", []string{
+			"routes.yaml:2: invalid priority \"high\"",
+			"routes.yaml:3: unknown field \"colour\"",
+			"routes.yaml:4: metadata should be a block of \"key: value\" lines",
+		}},
+		{"routes.json", "[\n  {\"method\": \"GET\",\n  \"pattern\": }\n]", []string{"routes.json:3: invalid character '}' looking for beginning of value"}},
+		{"routes.json", "[\n  {\"method\": 1, \"colour\": \"red\"},\n  \"GET /\"\n]", []string{
+			"routes.json:2: unknown field \"colour\"",
+			"routes.json:2: invalid method: 1",
+			"routes.json:3: route should be an object",
+		}},
+	}
+	for _, v := range items {
+		_, err := ParseRoutes(strings.NewReader(v.src), v.filename)
+		errs, ok := err.(ConfigErrors)
+		if !ok {
+			t.Fatalf("%q: should return ConfigErrors, got %v", v.src, err)
+		}
+		if err.Error() != strings.Join(v.errs, "\n") {
+			t.Fatalf("%q: should return\n%s\ngot\n%s", v.src, strings.Join(v.errs, "\n"), err)
+		}
+		if len(errs) != len(v.errs) {
+			t.Fatalf("%q: should return %d errors, got %d", v.src, len(v.errs), len(errs))
+		}
+	}
+}
+
+func TestLoadRoutes(t *testing.T) {
+	var calls []string
+	middleware := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next(w, r)
+			}
+		}
+	}
+	registry := Registry{
+		Handlers: map[string]http.HandlerFunc{
+			"users.show": func(w http.ResponseWriter, r *http.Request) {
+				route := RouteFromRequest(r)
+				w.Write([]byte(route.Name + " " + route.Metadata["team"] + " " + Param(r, ":id")))
+			},
+			"users.delete": func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+		},
+		Middleware: map[string]Middleware{
+			"auth": middleware("auth"),
+			"log":  middleware("log"),
+		},
+	}
+	routes, err := ParseRoutes(strings.NewReader(testYAMLRoutes), "routes.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mx := New()
+	if err := mx.LoadRoutes(routes, registry); err != nil {
+		t.Fatalf("should load routes, got %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mx.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	if w.Body.String() != "users.show accounts 42" || strings.Join(calls, ",") != "auth,log" {
+		t.Fatalf("should serve users.show with auth and log, got %q %v", w.Body.String(), calls)
+	}
+	w = httptest.NewRecorder()
+	mx.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/42", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("should serve users.delete, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	mx.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("should not register disabled routes, got %d", w.Code)
+	}
+
+	// all errors are reported and nothing is registered
+	mx = New()
+	mx.Get("/", func(w http.ResponseWriter, r *http.Request) {}).Name("home")
+	err = mx.LoadRoutes([]RouteConfig{
+		{Method: "GET", Pattern: "/a", Handler: "users.show", File: "routes.yaml", Line: 1},
+		{Method: "G T", Pattern: "/b", Handler: "users.show", File: "routes.yaml", Line: 2},
+		{Method: "GET", Pattern: "/c/:id(", Handler: "nope", Middleware: []string{"auth", "cache"}, File: "routes.yaml", Line: 3},
+		{Method: "get", Pattern: "/a", Handler: "users.show", File: "routes.yaml", Line: 4},
+		{Method: "GET", Pattern: "/", Handler: "users.show", File: "routes.yaml", Line: 5},
+		{Method: "POST", Pattern: "/d", Handler: "users.show", Name: "home", File: "routes.yaml", Line: 6},
+	}, registry)
+	expected := []string{
+		`routes.yaml:2: invalid method "G T"`,
+		`routes.yaml:3: handler "nope" is not registered`,
+		`routes.yaml:3: middleware "cache" is not registered`,
+		`routes.yaml:3: mux: invalid pattern "/c/:id(" at column 7: wrong regexp format "("`,
+		`routes.yaml:4: GET "/a" already defined`,
+		`routes.yaml:5: GET "/" already defined`,
+		`routes.yaml:6: route name "home" already used by "/"`,
+	}
+	if _, ok := err.(ConfigErrors); !ok || err.Error() != strings.Join(expected, "\n") {
+		t.Fatalf("should return\n%s\ngot\n%v", strings.Join(expected, "\n"), err)
+	}
+	if mx.trie.lookup("/a") != nil {
+		t.Fatal("should not register any route on error")
+	}
+}
+
+func TestLoadRoutesScratch(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	registry := Registry{Handlers: map[string]http.HandlerFunc{"h": h}}
+
+	// a conflict midway leaves nothing registered
+	mx := New(Options{Conflicts: ConflictError})
+	mx.Get("/users/:id", h)
+	err := mx.LoadRoutes([]RouteConfig{
+		{Method: "GET", Pattern: "/posts", Handler: "h", Name: "posts", Line: 1},
+		{Method: "GET", Pattern: "/users/:name", Handler: "h", Line: 2},
+		{Method: "GET", Pattern: "/tags", Handler: "h", Line: 3},
+	}, registry)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Fatalf("should return the conflict of line 2, got %v", err)
+	}
+	if routes := mx.Routes(); len(routes) != 1 || mx.trie.root.GetName("posts") != nil {
+		t.Fatalf("should not register any route on error, got %v", routes)
+	}
+
+	// metadata is set for the method of the route and copied
+	mx = New()
+	var metadata map[string]string
+	registry.Handlers["h"] = func(w http.ResponseWriter, r *http.Request) {
+		metadata = RouteFromRequest(r).Metadata
+		metadata["touched"] = "yes"
+	}
+	err = mx.LoadRoutes([]RouteConfig{
+		{Method: "GET", Pattern: "/users/:id", Handler: "h", Metadata: map[string]string{"cache": "60"}},
+		{Method: "DELETE", Pattern: "/users/:id", Handler: "h", Metadata: map[string]string{"audit": "yes"}},
+	}, registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct{ method, expect string }{{"GET", "60 "}, {"DELETE", " yes"}, {"GET", "60 "}} {
+		mx.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(v.method, "/users/1", nil))
+		if got := metadata["cache"] + " " + metadata["audit"]; got != v.expect {
+			t.Fatalf("%s should have metadata %q, got %q", v.method, v.expect, got)
+		}
+	}
+	for _, r := range mx.Routes() {
+		if r.Metadata["touched"] != "" || len(r.Metadata) != 1 {
+			t.Fatalf("%s %s: wrong metadata %v", r.Method, r.Pattern, r.Metadata)
+		}
+	}
+}
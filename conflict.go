@@ -0,0 +1,339 @@
+package mux
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ConflictPolicy defines what Trie does when a new pattern conflicts with
+// a registered one.
+type ConflictPolicy int
+
+const (
+	// ConflictAllow registers conflicting patterns silently, they are
+	// resolved by the matching order. It is the default policy.
+	ConflictAllow ConflictPolicy = iota
+	// ConflictWarn registers conflicting patterns and reports every conflict
+	// to Options.OnConflict, or to the standard logger if it is nil.
+	ConflictWarn
+	// ConflictError refuses conflicting patterns, Trie.TryParse returns the
+	// first *Conflict as error.
+	ConflictError
+)
+
+// ConflictKind describes how two patterns conflict.
+type ConflictKind int
+
+const (
+	// ConflictAmbiguous means both patterns can match some paths, which one
+	// wins depends on the registration order.
+	ConflictAmbiguous ConflictKind = iota
+	// ConflictShadowed means the new pattern can never be matched because
+	// the existing pattern is tried first and matches everything it would.
+	ConflictShadowed
+	// ConflictShadows means the existing pattern can never be matched any more
+	// because the new pattern is tried first and matches everything it would.
+	ConflictShadows
+)
+
+// Conflict is reported when a new pattern overlaps a registered one.
+type Conflict struct {
+	Kind ConflictKind
+
+	// Pattern is the new pattern.
+	Pattern string
+
+	// Existing is the registered pattern.
+	Existing string
+}
+
+func (c *Conflict) Error() string {
+	switch c.Kind {
+	case ConflictShadowed:
+		return fmt.Sprintf("mux: %q is shadowed by %q", c.Pattern, c.Existing)
+	case ConflictShadows:
+		return fmt.Sprintf("mux: %q shadows %q", c.Pattern, c.Existing)
+	}
+	return fmt.Sprintf("mux: %q is ambiguous with %q", c.Pattern, c.Existing)
+}
+
+// Conflicts returns the conflicts that would be introduced by parsing the pattern.
+// It does not modify the trie.
+//
+// Two patterns of the same length conflict when, at the first segment they
+// differ, both are params or regexp params that can match the same value:
+// the trie never backtracks, so the one tried first takes every such path.
+// Which one is tried first follows the priorities of the routes, the new
+// pattern has the default priority unless it is registered.
+// Static segments always win over params, so they never conflict, and
+// wildcards are treated as intended fallbacks. An optional last param also
+// conflicts with a route matching the path without it.
+//
+//  trie.Parse("/abc/:id")
+//  trie.Conflicts("/abc/:id:int")
+//  // [mux: "/abc/:id:int" is shadowed by "/abc/:id"]
+func (t *Trie) Conflicts(pattern string) []*Conflict {
+	colonPattern, _, err := t.normalize(pattern)
+	if err != nil {
+		return nil
+	}
+	_pattern := strings.TrimPrefix(colonPattern, "/")
+	if !t.caseSensitive {
+		_pattern = strings.ToLower(_pattern)
+	}
+	segments := strings.Split(_pattern, "/")
+
+	var conflicts []*Conflict
+	t.root.walk(func(n *Node) {
+		if !n.endpoint {
+			return
+		}
+		existing := n.getSegmentList()
+		if equalSegments(segments, existing) {
+			return
+		}
+		if kind, ok := t.conflictKind(segments, existing); ok {
+			conflicts = append(conflicts, &Conflict{Kind: kind, Pattern: pattern, Existing: n.pattern})
+		}
+	})
+	return conflicts
+}
+
+// checkConflicts applies the conflict policy of the trie to the pattern.
+func (t *Trie) checkConflicts(pattern string) error {
+	if t.conflictPolicy == ConflictAllow || t.lookup(pattern) != nil {
+		return nil
+	}
+	conflicts := t.Conflicts(pattern)
+	if len(conflicts) == 0 {
+		return nil
+	}
+	if t.conflictPolicy == ConflictError {
+		return conflicts[0]
+	}
+	for _, c := range conflicts {
+		if t.onConflict != nil {
+			t.onConflict(c)
+		} else {
+			log.Print(c)
+		}
+	}
+	return nil
+}
+
+// lookup returns the endpoint node of a registered pattern, or nil.
+func (t *Trie) lookup(pattern string) *Node {
+	pattern, _, err := t.normalize(pattern)
+	if err != nil {
+		return nil
+	}
+	_pattern := strings.TrimPrefix(pattern, "/")
+	if !t.caseSensitive {
+		_pattern = strings.ToLower(_pattern)
+	}
+	node := t.root
+	for _, segment := range strings.Split(_pattern, "/") {
+		if node = node.getChild(segment); node == nil {
+			return nil
+		}
+	}
+	if !node.endpoint {
+		return nil
+	}
+	return node
+}
+
+// conflictKind compares a new pattern with an existing one, both as split segments.
+func (t *Trie) conflictKind(segments, existing []string) (ConflictKind, bool) {
+	for _, a := range segmentVariants(segments) {
+		for _, b := range segmentVariants(existing) {
+			if len(a) != len(b) || isWildcardSegments(a) || isWildcardSegments(b) {
+				continue
+			}
+			i := 0
+			for i < len(a) && a[i] == b[i] {
+				i++
+			}
+			if i == len(a) {
+				// the path without the optional param is matched by both
+				return ConflictAmbiguous, true
+			}
+			sa, sb := newSegmentInfo(a[i]), newSegmentInfo(b[i])
+			if sa.static || sb.static || !sa.overlaps(sb) {
+				continue
+			}
+			if t.triedFirst(a[:i+1], b[:i+1], sa, sb) {
+				if sa.covers(sb) {
+					return ConflictShadows, true
+				}
+			} else if sb.covers(sa) {
+				return ConflictShadowed, true
+			}
+			return ConflictAmbiguous, true
+		}
+	}
+	return 0, false
+}
+
+// triedFirst reports whether the node of the new pattern a is tried before
+// the node of the existing pattern b, siblings in the trie: named params
+// before regexp params, then by descending rank and adding order. The new
+// pattern is added last with the default priority unless it is registered.
+func (t *Trie) triedFirst(a, b []string, sa, sb segmentInfo) bool {
+	if (sa.regexp == nil) != (sb.regexp == nil) {
+		return sa.regexp == nil
+	}
+	na, nb := t.root.getDescendant(a), t.root.getDescendant(b)
+	if nb == nil {
+		return false
+	}
+	if na == nil {
+		return nb.rank < 0
+	}
+	return na.rank > nb.rank || na.rank == nb.rank && na.order < nb.order
+}
+
+// getDescendant returns the node of the segments under n, or nil.
+func (n *Node) getDescendant(segments []string) *Node {
+	for _, segment := range segments {
+		if n = n.getChild(segment); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// segmentVariants returns the segments, and the segments without the
+// last one when it is an optional param.
+func segmentVariants(segments []string) [][]string {
+	variants := [][]string{segments}
+	if last := segments[len(segments)-1]; newSegmentInfo(last).optional && len(segments) > 1 {
+		variants = append(variants, segments[:len(segments)-1])
+	}
+	return variants
+}
+
+func isWildcardSegments(segments []string) bool {
+	for _, s := range segments {
+		if isWildcardSegment(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalSegments(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentInfo describes what a single pattern segment can match.
+type segmentInfo struct {
+	static, optional bool
+	// regexp is nil for named params
+	regexp *segmentRegexp
+}
+
+type segmentRegexp struct {
+	expr, prefix, suffix string
+}
+
+func newSegmentInfo(segment string) segmentInfo {
+	switch {
+	case segment == "" || strings.Contains(segment, "::"):
+		return segmentInfo{static: true}
+	case optionalParamRegexp.MatchString(segment):
+		return segmentInfo{optional: true}
+	case paramRegexp.MatchString(segment):
+		return segmentInfo{}
+	case isRegexpSegment(segment):
+		_, r, optional, err := parseRegexpSegment(segment)
+		if err != nil {
+			return segmentInfo{static: true}
+		}
+		expr := r.String()
+		info := segmentInfo{optional: optional, regexp: &segmentRegexp{expr: expr}}
+		if i := strings.IndexByte(expr, '('); i >= 0 {
+			info.regexp.prefix = expr[:i]
+			info.regexp.suffix = expr[strings.LastIndexByte(expr, ')')+1:]
+		}
+		return info
+	}
+	return segmentInfo{static: true}
+}
+
+// overlaps reports whether both params may match the same value.
+// Regexps are compared by their literal prefix and suffix only.
+func (s segmentInfo) overlaps(o segmentInfo) bool {
+	if s.regexp == nil || o.regexp == nil {
+		return true
+	}
+	return compatibleAffix(s.regexp.prefix, o.regexp.prefix, strings.HasPrefix) &&
+		compatibleAffix(s.regexp.suffix, o.regexp.suffix, strings.HasSuffix)
+}
+
+func compatibleAffix(a, b string, has func(s, affix string) bool) bool {
+	return has(a, b) || has(b, a)
+}
+
+// covers reports whether s matches every value o matches: s is a named
+// param, or both regexps are the same but for :string params of s where o
+// has :int params, as [0-9] is in \w.
+func (s segmentInfo) covers(o segmentInfo) bool {
+	if s.regexp == nil {
+		return true
+	}
+	if o.regexp == nil {
+		return false
+	}
+	const intRule, stringRule = "([0-9]+)", `([\w]+)`
+	a, b := s.regexp.expr, o.regexp.expr
+	for a != "" && b != "" {
+		switch {
+		case strings.HasPrefix(a, stringRule) && strings.HasPrefix(b, intRule):
+			a, b = a[len(stringRule):], b[len(intRule):]
+		case a[0] == b[0]:
+			a, b = a[1:], b[1:]
+		default:
+			return false
+		}
+	}
+	return a == b
+}
+
+// walk calls fn for the node and all its descendants, static children
+// first in key order, then params and regexps in matching order.
+func (n *Node) walk(fn func(*Node)) {
+	fn(n)
+	keys := make([]string, 0, len(n.children))
+	for k := range n.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		n.children[k].walk(fn)
+	}
+	for _, c := range n.segChildren {
+		c.walk(fn)
+	}
+	for _, c := range n.varyChildren {
+		c.walk(fn)
+	}
+}
+
+// getSegmentList returns the segments from the root to the node.
+func (n *Node) getSegmentList() []string {
+	if n.parent == nil {
+		return nil
+	}
+	return append(n.parent.getSegmentList(), n.segment)
+}
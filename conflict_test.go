@@ -0,0 +1,124 @@
+package mux
+
+import (
+	"testing"
+)
+
+func TestConflicts(t *testing.T) {
+	items := []struct {
+		existing []string
+		pattern  string
+		kind     ConflictKind
+		conflict bool
+	}{
+		{[]string{"/abc/:id"}, "/abc/:id:int", ConflictShadowed, true},
+		{[]string{"/abc/:id:int"}, "/abc/:id", ConflictShadows, true},
+		{[]string{"/abc/:id"}, "/abc/:name", ConflictShadowed, true},
+		{[]string{"/abc/:id:int"}, "/abc/:id([0-9]+)", ConflictShadowed, true},
+		{[]string{"/xyz/?:name:string"}, "/xyz/?:id:int", ConflictShadowed, true},
+		{[]string{"/abc/:name:string"}, "/abc/:id:int", ConflictShadowed, true},
+		{[]string{"/abc/:id:int"}, "/abc/:name:string", ConflictAmbiguous, true},
+		{[]string{"/abc/x:a:string_:b:int"}, "/abc/x:a:int_:b:int", ConflictShadowed, true},
+		{[]string{"/abc/x:a:int_:b:int"}, "/abc/x:a:string_:b:int", ConflictAmbiguous, true},
+		{[]string{"/abc/x:a:int_:b:string"}, "/abc/x:a:string_:b:int", ConflictAmbiguous, true},
+		{[]string{"/abc/:id/x"}, "/abc/:name/y", ConflictShadowed, true},
+		{[]string{"/abc"}, "/abc/?:id", ConflictAmbiguous, true},
+		{[]string{"/abc/?:id"}, "/abc", ConflictAmbiguous, true},
+		{[]string{"/abc/:id"}, "/abc/:id", 0, false},
+		{[]string{"/abc/:id"}, "/abc/new", 0, false},
+		{[]string{"/abc/new"}, "/abc/:id", 0, false},
+		{[]string{"/abc/:id"}, "/abc/:id/x", 0, false},
+		{[]string{"/abc/:id"}, "/abc/*", 0, false},
+		{[]string{"/abc/article_:id:int"}, "/abc/page_:id:int", 0, false},
+		{[]string{"/abc/:id.html"}, "/abc/:id.json", 0, false},
+		{[]string{"/abc/:id/x"}, "/xyz/:name/y", 0, false},
+	}
+	for _, v := range items {
+		tr := NewTrie()
+		for _, p := range v.existing {
+			tr.Parse(p)
+		}
+		conflicts := tr.Conflicts(v.pattern)
+		if !v.conflict {
+			if len(conflicts) != 0 {
+				t.Fatalf("%s with %v should not conflict, got %v", v.pattern, v.existing, conflicts)
+			}
+			continue
+		}
+		if len(conflicts) != 1 || conflicts[0].Kind != v.kind ||
+			conflicts[0].Pattern != v.pattern || conflicts[0].Existing != v.existing[0] {
+			t.Fatalf("%s with %v should conflict with kind %d, got %v", v.pattern, v.existing, v.kind, conflicts)
+		}
+	}
+}
+
+func TestConflictsPriority(t *testing.T) {
+	items := []struct {
+		existing string
+		priority int
+		pattern  string
+		kind     ConflictKind
+	}{
+		// the new pattern is tried first when the existing one has a negative priority
+		{"/abc/:name", -1, "/abc/:id", ConflictShadows},
+		{"/abc/:name", 1, "/abc/:id", ConflictShadowed},
+		{"/abc/:id([0-9]+)", -1, "/abc/:num:int", ConflictShadows},
+		{"/abc/:name:string", -1, "/abc/:id:int", ConflictAmbiguous},
+		// named params are tried before regexp params whatever the priority
+		{"/abc/:name", -1, "/abc/:id:int", ConflictShadowed},
+	}
+	for _, v := range items {
+		tr := NewTrie()
+		tr.Parse(v.existing).Priority(v.priority)
+		conflicts := tr.Conflicts(v.pattern)
+		if len(conflicts) != 1 || conflicts[0].Kind != v.kind {
+			t.Fatalf("%s with %s (%d) should conflict with kind %d, got %v", v.pattern, v.existing, v.priority, v.kind, conflicts)
+		}
+	}
+
+	// a registered pattern is compared with its own priority
+	tr := NewTrie()
+	tr.Parse("/abc/:name")
+	tr.Parse("/abc/:id").Priority(10)
+	conflicts := tr.Conflicts("/abc/:id")
+	if len(conflicts) != 1 || conflicts[0].Kind != ConflictShadows {
+		t.Fatalf("/abc/:id with priority should be tried first, got %v", conflicts)
+	}
+	// and so are the routes under a param
+	tr = NewTrie()
+	tr.Parse("/abc/:name/x")
+	tr.Parse("/abc/:id/y").Priority(10)
+	conflicts = tr.Conflicts("/abc/:id/y")
+	if len(conflicts) != 1 || conflicts[0].Kind != ConflictShadows {
+		t.Fatalf("/abc/:id/y with priority should be tried first, got %v", conflicts)
+	}
+}
+
+func TestConflictPolicy(t *testing.T) {
+	tr := NewTrie(Options{Conflicts: ConflictError})
+	tr.Parse("/abc/:id")
+	if _, err := tr.TryParse("/abc/:id"); err != nil {
+		t.Fatalf("reparsing a pattern should not conflict, got %v", err)
+	}
+	n, err := tr.TryParse("/abc/:id:int")
+	if c, ok := err.(*Conflict); n != nil || !ok || c.Kind != ConflictShadowed {
+		t.Fatalf("should return *Conflict, got %#v", err)
+	}
+	if len(tr.root.getChild("abc").varyChildren) != 0 {
+		t.Fatal("conflicting pattern should not be parsed")
+	}
+
+	var conflicts []*Conflict
+	tr = NewTrie(Options{Conflicts: ConflictWarn, OnConflict: func(c *Conflict) {
+		conflicts = append(conflicts, c)
+	}})
+	tr.Parse("/abc/:id")
+	tr.Parse("/abc/:name")
+	tr.Parse("/abc/:name")
+	if len(conflicts) != 1 || conflicts[0].Error() != `mux: "/abc/:name" is shadowed by "/abc/:id"` {
+		t.Fatalf("should warn once, got %v", conflicts)
+	}
+	if tr.root.getChild("abc").getChild(":name") == nil {
+		t.Fatal("conflicting pattern should be parsed with ConflictWarn")
+	}
+}
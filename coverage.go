@@ -0,0 +1,99 @@
+package mux
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+)
+
+// Coverage records the routes of a Mux handling requests, to find the
+// routes which are not exercised by tests. See Mux.RecordCoverage.
+type Coverage struct {
+	mu   sync.Mutex
+	mux  *Mux
+	hits map[metricsKey]uint64
+}
+
+// RecordCoverage starts recording the routes handling requests, keyed by
+// method and pattern. A route registered with Any is covered by any method
+// without a handler of its own.
+//
+//  var coverage = router.RecordCoverage()
+//
+//  func TestMain(m *testing.M) {
+//  	code := m.Run()
+//  	coverage.WriteReport(os.Stdout)
+//  	os.Exit(code)
+//  }
+func (m *Mux) RecordCoverage() *Coverage {
+	m.coverage = &Coverage{mux: m, hits: make(map[metricsKey]uint64)}
+	return m.coverage
+}
+
+func (c *Coverage) record(node *Node, method string) {
+	method = node.handlerMethod(method)
+	c.mu.Lock()
+	c.hits[metricsKey{method: method, pattern: node.pattern}]++
+	c.mu.Unlock()
+}
+
+// Hits returns the number of requests handled by the route.
+func (c *Coverage) Hits(method, pattern string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(c.hits[metricsKey{method: method, pattern: pattern}])
+}
+
+// Untested returns the registered routes which have not handled any request.
+func (c *Coverage) Untested() []RouteInfo {
+	var untested []RouteInfo
+	for _, r := range c.mux.Routes() {
+		if c.Hits(r.Method, r.Pattern) == 0 {
+			untested = append(untested, r)
+		}
+	}
+	return untested
+}
+
+// Percent returns the percentage of the registered routes which have
+// handled requests, 100 if there is no route.
+func (c *Coverage) Percent() float64 {
+	routes := c.mux.Routes()
+	if len(routes) == 0 {
+		return 100
+	}
+	return 100 * float64(len(routes)-len(c.Untested())) / float64(len(routes))
+}
+
+// WriteReport writes the registered routes with their number of requests,
+// the untested ones marked, and the coverage percentage.
+//
+//  METHOD  PATTERN     HITS
+//  GET     /users      3
+//  GET     /users/:id  0  untested
+//  coverage: 1 of 2 routes (50.0%)
+func (c *Coverage) WriteReport(w io.Writer) error {
+	routes := c.mux.Routes()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tHITS")
+	covered := 0
+	for _, r := range routes {
+		hits := c.Hits(r.Method, r.Pattern)
+		if hits == 0 {
+			fmt.Fprintf(tw, "%s\t%s\t%d\tuntested\n", r.Method, r.Pattern, hits)
+			continue
+		}
+		covered++
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", r.Method, r.Pattern, hits)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	percent := 100.0
+	if len(routes) > 0 {
+		percent = 100 * float64(covered) / float64(len(routes))
+	}
+	_, err := fmt.Fprintf(w, "coverage: %d of %d routes (%.1f%%)\n", covered, len(routes), percent)
+	return err
+}
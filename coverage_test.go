@@ -0,0 +1,58 @@
+package mux
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	mx := New()
+	mx.Get("/users", h)
+	mx.Get("/users/:id", h)
+	mx.Delete("/users/:id", h)
+	mx.Any("/ping", h)
+	coverage := mx.RecordCoverage()
+
+	for _, v := range [][2]string{
+		{"GET", "/users"},
+		{"GET", "/users"},
+		{"GET", "/users/1"},
+		{"POST", "/users/1"},
+		{"GET", "/unknown"},
+		{"HEAD", "/ping"},
+	} {
+		mx.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(v[0], v[1], nil))
+	}
+
+	if hits := coverage.Hits("GET", "/users"); hits != 2 {
+		t.Fatalf("should record 2 hits, got %d", hits)
+	}
+	if hits := coverage.Hits(MethodAny, "/ping"); hits != 1 {
+		t.Fatalf("should record the hit of Any, got %d", hits)
+	}
+	untested := coverage.Untested()
+	if len(untested) != 1 || untested[0].Method != "DELETE" || untested[0].Pattern != "/users/:id" {
+		t.Fatalf("should return DELETE /users/:id untested, got %+v", untested)
+	}
+	if p := coverage.Percent(); p != 75 {
+		t.Fatalf("should return 75%%, got %v", p)
+	}
+
+	var b bytes.Buffer
+	if err := coverage.WriteReport(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `METHOD  PATTERN     HITS
+*       /ping       1
+GET     /users      2
+DELETE  /users/:id  0  untested
+GET     /users/:id  1
+coverage: 3 of 4 routes (75.0%)
+`
+	if b.String() != expected {
+		t.Fatalf("should write\n%s\ngot\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,299 @@
+package mux
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ChangeKind describes how a route changed between two Mux.
+type ChangeKind string
+
+const (
+	// RouteAdded is a pattern only in the new Mux.
+	RouteAdded ChangeKind = "added"
+	// RouteRemoved is a pattern only in the old Mux.
+	RouteRemoved ChangeKind = "removed"
+	// RouteChanged is a pattern in both Mux with different methods, name,
+	// constraints or param names, or with new conflicts.
+	RouteChanged ChangeKind = "changed"
+)
+
+// RouteChange is a difference of a route between two Mux, see Diff.
+type RouteChange struct {
+	Kind ChangeKind
+
+	// Pattern is the pattern in the new Mux, or in the old one if removed.
+	Pattern string
+
+	// OldPattern is the pattern in the old Mux if it changed, e.g. a param
+	// constraint "/users/:id" changed to "/users/:id:int".
+	OldPattern string
+
+	// Methods are the methods of an added or removed route.
+	Methods []string
+
+	// AddedMethods and RemovedMethods are the changed methods of a route.
+	AddedMethods   []string
+	RemovedMethods []string
+
+	// OldName and Name are the names of the route if it changed.
+	OldName, Name string
+
+	// Conflicts are the conflicts of the route in the new Mux with
+	// patterns which were not both registered in the old Mux.
+	Conflicts []*Conflict
+
+	// the patterns in colon syntax, for patterns in brace syntax
+	oldColonPattern, colonPattern string
+}
+
+// Breaking reports whether the change can break clients or URL building:
+// a removed route or method, a renamed route, a renamed param or a param
+// with a new or changed constraint. Removing a constraint is not breaking.
+func (c RouteChange) Breaking() bool {
+	old, cur := c.OldPattern, c.Pattern
+	if c.colonPattern != "" {
+		old, cur = c.oldColonPattern, c.colonPattern
+	}
+	return c.Kind == RouteRemoved || len(c.RemovedMethods) > 0 || c.OldName != c.Name && c.OldName != "" ||
+		c.OldPattern != "" && narrowsParams(old, cur)
+}
+
+// narrowsParams reports whether a param of cur, paired by skeleton with old,
+// is renamed or has a new or changed constraint. The patterns are in colon
+// syntax.
+//
+//  narrowsParams("/users/:id", "/users/:id:int")     // true
+//  narrowsParams("/files/*filepath", "/files/*path") // true
+//  narrowsParams("/users/:id([0-9]+)", "/users/:id") // false
+func narrowsParams(old, cur string) bool {
+	o, c := skeletonRegexp.FindAllStringSubmatch(old, -1), skeletonRegexp.FindAllStringSubmatch(cur, -1)
+	if len(o) != len(c) {
+		return true
+	}
+	for i := range o {
+		if strings.TrimSuffix(o[i][0], o[i][1]) != strings.TrimSuffix(c[i][0], c[i][1]) {
+			return true
+		}
+		if c[i][1] != "" && c[i][1] != o[i][1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (c RouteChange) String() string {
+	var b bytes.Buffer
+	switch c.Kind {
+	case RouteAdded:
+		fmt.Fprintf(&b, "+ %s %s", strings.Join(c.Methods, ","), c.Pattern)
+	case RouteRemoved:
+		fmt.Fprintf(&b, "- %s %s", strings.Join(c.Methods, ","), c.Pattern)
+	default:
+		fmt.Fprintf(&b, "~ %s", c.Pattern)
+	}
+	var details []string
+	if c.OldPattern != "" {
+		details = append(details, fmt.Sprintf("pattern %q -> %q", c.OldPattern, c.Pattern))
+	}
+	for _, m := range c.AddedMethods {
+		details = append(details, "+"+m)
+	}
+	for _, m := range c.RemovedMethods {
+		details = append(details, "-"+m)
+	}
+	if c.OldName != c.Name {
+		details = append(details, fmt.Sprintf("name %q -> %q", c.OldName, c.Name))
+	}
+	for _, conflict := range c.Conflicts {
+		details = append(details, conflict.Error())
+	}
+	if len(details) > 0 {
+		b.WriteString(": ")
+		b.WriteString(strings.Join(details, "; "))
+	}
+	return b.String()
+}
+
+// Diff returns the routes added, removed and changed from a to b, sorted by
+// pattern. Patterns are compared exactly, then by their skeleton without
+// param names and constraints, so that "/users/:id" changed to
+// "/users/:uid:int" is a changed route. Conflicts introduced in b are
+// reported on the added or changed routes.
+//
+//  for _, c := range mux.Diff(previous, current) {
+//  	if c.Breaking() {
+//  		t.Error(c)
+//  	}
+//  }
+func Diff(a, b *Mux) []RouteChange {
+	old, cur := diffRoutes(a.trie), diffRoutes(b.trie)
+
+	// pair the patterns, exactly then by unique skeleton
+	pairs := make(map[string]string)
+	for p := range cur {
+		if _, ok := old[p]; ok {
+			pairs[p] = p
+		}
+	}
+	oldSkeletons := skeletonIndex(old, pairs, true)
+	for s, p := range skeletonIndex(cur, pairs, false) {
+		if o, ok := oldSkeletons[s]; ok && p != "" && o != "" {
+			pairs[p] = o
+		}
+	}
+	paired := make(map[string]bool)
+	for _, o := range pairs {
+		paired[o] = true
+	}
+
+	var changes []RouteChange
+	reported := make(map[[2]string]bool)
+	patterns := make([]string, 0, len(cur))
+	for p := range cur {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+	for _, p := range patterns {
+		r := cur[p]
+		o, ok := pairs[p]
+		if !ok {
+			c := RouteChange{Kind: RouteAdded, Pattern: p, Methods: r.methods, Name: r.name}
+			c.Conflicts = newConflicts(a.trie, b.trie, p, reported)
+			changes = append(changes, c)
+			continue
+		}
+		or := old[o]
+		c := RouteChange{Kind: RouteChanged, Pattern: p, OldName: or.name, Name: r.name}
+		if o != p {
+			c.OldPattern = o
+			c.oldColonPattern, c.colonPattern = or.colonPattern, r.colonPattern
+			c.Conflicts = newConflicts(a.trie, b.trie, p, reported)
+		}
+		c.AddedMethods = subtract(r.methods, or.methods)
+		c.RemovedMethods = subtract(or.methods, r.methods)
+		if c.OldPattern != "" || len(c.AddedMethods) > 0 || len(c.RemovedMethods) > 0 ||
+			c.OldName != c.Name || len(c.Conflicts) > 0 {
+			changes = append(changes, c)
+		}
+	}
+	for o, r := range old {
+		if !paired[o] {
+			changes = append(changes, RouteChange{Kind: RouteRemoved, Pattern: o, Methods: r.methods, OldName: r.name})
+		}
+	}
+	sort.Sort(routeChanges(changes))
+	return changes
+}
+
+type diffRoute struct {
+	name         string
+	methods      []string
+	colonPattern string
+}
+
+// diffRoutes returns the routes of the trie by pattern.
+func diffRoutes(t *Trie) map[string]*diffRoute {
+	routes := make(map[string]*diffRoute)
+	for _, r := range t.Routes() {
+		dr, ok := routes[r.Pattern]
+		if !ok {
+			dr = &diffRoute{name: r.Name, colonPattern: r.Pattern}
+			if n := t.lookup(r.Pattern); n != nil {
+				dr.colonPattern = n.colonPattern
+			}
+			routes[r.Pattern] = dr
+		}
+		dr.methods = append(dr.methods, r.Method)
+	}
+	return routes
+}
+
+// skeletonIndex returns the unpaired patterns by skeleton, with an empty
+// pattern for a skeleton shared by several patterns. The paired patterns
+// are the values of pairs for the old routes, the keys for the new ones.
+func skeletonIndex(routes map[string]*diffRoute, pairs map[string]string, old bool) map[string]string {
+	taken := make(map[string]bool)
+	for p, o := range pairs {
+		if old {
+			taken[o] = true
+		} else {
+			taken[p] = true
+		}
+	}
+	index := make(map[string]string)
+	for p := range routes {
+		if taken[p] {
+			continue
+		}
+		s := skeleton(routes[p].colonPattern)
+		if _, ok := index[s]; ok {
+			index[s] = ""
+		} else {
+			index[s] = p
+		}
+	}
+	return index
+}
+
+// skeletonRegexp matches the params of a pattern in colon syntax with their
+// constraint, whose regexp can have groups such as (^(?:en|fr)$).
+var skeletonRegexp = regexp.MustCompile(`:\w+(:int|:string|\((?:[^()]|\([^()]*\))*\))?|\*\w+`)
+
+// skeleton returns the pattern in colon syntax without param names and
+// constraints, e.g. "/users/:" for "/users/:id:int".
+func skeleton(pattern string) string {
+	return skeletonRegexp.ReplaceAllStringFunc(pattern, func(s string) string {
+		return s[:1]
+	})
+}
+
+// newConflicts returns the conflicts of the pattern in b with patterns
+// not both registered in a, which have not been reported yet.
+func newConflicts(a, b *Trie, pattern string, reported map[[2]string]bool) []*Conflict {
+	var conflicts []*Conflict
+	for _, c := range b.Conflicts(pattern) {
+		if c.Existing == pattern || a.lookup(c.Pattern) != nil && a.lookup(c.Existing) != nil {
+			continue
+		}
+		key := [2]string{c.Pattern, c.Existing}
+		if key[0] > key[1] {
+			key[0], key[1] = key[1], key[0]
+		}
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+		conflicts = append(conflicts, c)
+	}
+	return conflicts
+}
+
+// subtract returns the strings of a not in b.
+func subtract(a, b []string) []string {
+	var s []string
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			found = found || v == w
+		}
+		if !found {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+type routeChanges []RouteChange
+
+func (c routeChanges) Len() int      { return len(c) }
+func (c routeChanges) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c routeChanges) Less(i, j int) bool {
+	if c[i].Pattern != c[j].Pattern {
+		return c[i].Pattern < c[j].Pattern
+	}
+	return c[i].Kind < c[j].Kind
+}
@@ -0,0 +1,110 @@
+package mux
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	a := New()
+	a.Get("/users", h)
+	a.Post("/users", h)
+	a.Get("/users/:id", h).Name("users.show")
+	a.Delete("/users/:id", h)
+	a.Get("/posts/:slug", h)
+	a.Get("/legacy", h)
+	a.Get("/files/*filepath", h)
+
+	b := New()
+	b.Get("/users", h)
+	b.Post("/users", h)
+	b.Get("/users/:id:int", h).Name("users.get")
+	b.Put("/users/:id:int", h)
+	b.Get("/posts/:slug", h)
+	b.Get("/posts/:id", h)
+	b.Get("/files/*path", h)
+	b.Get("/health", h)
+
+	changes := Diff(a, b)
+	expected := []string{
+		`~ /files/*path: pattern "/files/*filepath" -> "/files/*path"`,
+		`+ GET /health`,
+		`- GET /legacy`,
+		`+ GET /posts/:id: mux: "/posts/:id" is shadowed by "/posts/:slug"`,
+		`~ /users/:id:int: pattern "/users/:id" -> "/users/:id:int"; +PUT; -DELETE; name "users.show" -> "users.get"`,
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("should return\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	breaking := map[string]bool{"/legacy": true, "/users/:id:int": true, "/files/*path": true}
+	for _, c := range changes {
+		if c.Breaking() != breaking[c.Pattern] {
+			t.Fatalf("%s: breaking should be %t", c, breaking[c.Pattern])
+		}
+	}
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Fatalf("should return no change for the same Mux, got %v", changes)
+	}
+}
+
+func TestDiffBreakingParams(t *testing.T) {
+	items := []struct {
+		old, cur string
+		breaking bool
+	}{
+		{"/users/:id", "/users/:id:int", true},
+		{"/users/:id", "/users/:id([0-9]+)", true},
+		{"/users/:id:string", "/users/:id:int", true},
+		{"/users/:id([0-9]+)", "/users/:id([0-9]{1,6})", true},
+		{"/users/:id", "/users/:uid", true},
+		{"/files/*filepath", "/files/*path", true},
+		{"/users/:id:int", "/users/:id", false},
+		{"/users/:id([0-9]+)", "/users/:id", false},
+		{"/posts/:year:int/:slug", "/posts/:year/:slug", false},
+	}
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	for _, v := range items {
+		a, b := New(), New()
+		a.Get(v.old, h)
+		b.Get(v.cur, h)
+		changes := Diff(a, b)
+		if len(changes) != 1 || changes[0].OldPattern != v.old {
+			t.Fatalf("%s -> %s: should be a changed pattern, got %v", v.old, v.cur, changes)
+		}
+		if changes[0].Breaking() != v.breaking {
+			t.Fatalf("%s: breaking should be %t", changes[0], v.breaking)
+		}
+	}
+}
+
+func TestDiffBraceSyntax(t *testing.T) {
+	items := []struct {
+		old, cur string
+		breaking bool
+	}{
+		{"/users/{id}", "/users/{id:[0-9]+}", true},
+		{"/users/{id}", "/users/{uid}", true},
+		{"/users/{id:[0-9]+}", "/users/{id}", false},
+		{"/files/{path...}", "/files/{rest...}", true},
+	}
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	for _, v := range items {
+		a, b := New(Options{BraceSyntax: true}), New(Options{BraceSyntax: true})
+		a.Get(v.old, h)
+		b.Get(v.cur, h)
+		changes := Diff(a, b)
+		if len(changes) != 1 || changes[0].OldPattern != v.old || changes[0].Pattern != v.cur {
+			t.Fatalf("%s -> %s: should be a changed pattern, got %v", v.old, v.cur, changes)
+		}
+		if changes[0].Breaking() != v.breaking {
+			t.Fatalf("%s: breaking should be %t", changes[0], v.breaking)
+		}
+	}
+}
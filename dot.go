@@ -0,0 +1,102 @@
+package mux
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// dotStyles are the node attributes by kind.
+var dotStyles = map[NodeKind]string{
+	KindStatic:   `shape=box`,
+	KindParam:    `shape=ellipse, style=filled, fillcolor=lightblue`,
+	KindOptional: `shape=ellipse, style="filled,dashed", fillcolor=lightblue`,
+	KindRegexp:   `shape=hexagon, style=filled, fillcolor=lightyellow`,
+	KindWildcard: `shape=octagon, style=filled, fillcolor=salmon`,
+}
+
+// dotEdges are the edge attributes by list of children.
+var dotEdges = []struct{ list, attrs string }{
+	{"children", `color=black`},
+	{"segChildren", `color=blue`},
+	{"optionChildren", `color=blue, style=dotted`},
+	{"varyChildren", `color=red, style=dashed`},
+}
+
+// WriteDOT writes the nodes of the trie as a Graphviz DOT graph.
+// Static nodes are boxes, params blue ellipses, dashed if optional,
+// regexps yellow hexagons and wildcards red octagons. Endpoints have a double
+// border and list the methods of their handlers. Edges are labeled with the
+// list holding the child, which is the order they are tried in: children,
+// then segChildren (with optionChildren) and varyChildren.
+//
+//  trie.WriteDOT(os.Stdout)
+//  // dot -Tsvg trie.dot > trie.svg
+func (t *Trie) WriteDOT(w io.Writer) error {
+	var b bytes.Buffer
+	ids := make(map[*Node]int)
+	b.WriteString("digraph mux {\n\trankdir=LR;\n\tnode [fontname=\"Helvetica\"];\n\tedge [fontname=\"Helvetica\", fontsize=10];\n")
+	t.root.walk(func(n *Node) {
+		id := len(ids)
+		ids[n] = id
+		fmt.Fprintf(&b, "\tn%d [label=\"%s\", %s", id, dotLabel(n), dotStyles[n.Kind()])
+		if len(n.methods()) > 0 {
+			b.WriteString(", peripheries=2")
+		}
+		b.WriteString("];\n")
+	})
+	t.root.walk(func(n *Node) {
+		keys := make([]string, 0, len(n.children))
+		for k := range n.children {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		lists := [][]*Node{nil, n.segChildren, n.optionChildren, n.varyChildren}
+		for _, k := range keys {
+			lists[0] = append(lists[0], n.children[k])
+		}
+		for i, list := range lists {
+			for _, c := range list {
+				fmt.Fprintf(&b, "\tn%d -> n%d [label=\"%s\", %s];\n", ids[n], ids[c], dotEdges[i].list, dotEdges[i].attrs)
+			}
+		}
+	})
+	b.WriteString("}\n")
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// WriteDOT writes the routing trie as a Graphviz DOT graph, see Trie.WriteDOT.
+func (m *Mux) WriteDOT(w io.Writer) error {
+	return m.trie.WriteDOT(w)
+}
+
+// dotLabel returns the escaped label of the node: its segment, then the
+// methods and name of its route, and its priority.
+func dotLabel(n *Node) string {
+	lines := []string{n.segment}
+	switch {
+	case n.parent == nil:
+		lines[0] = "/"
+	case n.segment == "":
+		lines[0] = "(trailing slash)"
+	}
+	if methods := n.methods(); len(methods) > 0 {
+		sort.Strings(methods)
+		lines = append(lines, strings.Join(methods, " "))
+	}
+	if n.routeName != "" {
+		lines = append(lines, "name: "+n.routeName)
+	}
+	if n.priority != 0 {
+		lines = append(lines, fmt.Sprintf("priority: %d", n.priority))
+	}
+	for i, l := range lines {
+		lines[i] = dotEscaper.Replace(l)
+	}
+	return strings.Join(lines, `\n`)
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
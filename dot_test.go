@@ -0,0 +1,51 @@
+package mux
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	trie := NewTrie()
+	trie.Parse("/users").Handle("GET", "list")
+	trie.Parse("/users").Handle("POST", "create")
+	trie.Parse("/users/:id").Name("users.show").Handle("GET", "show")
+	trie.Parse("/users/?:page").Handle("GET", "page")
+	trie.Parse(`/files/:name(\w+).txt`).Priority(2).Handle("GET", "text")
+	trie.Parse("/files/*filepath").Handle("GET", "file")
+	trie.Parse(`/say/"hi"/`).Handle("GET", "hi")
+
+	var b bytes.Buffer
+	if err := trie.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph mux {
+	rankdir=LR;
+	node [fontname="Helvetica"];
+	edge [fontname="Helvetica", fontsize=10];
+	n0 [label="/", shape=box];
+	n1 [label="files", shape=box];
+	n2 [label=":name(\\w+).txt\nGET\npriority: 2", shape=hexagon, style=filled, fillcolor=lightyellow, peripheries=2];
+	n3 [label="*filepath\nGET", shape=octagon, style=filled, fillcolor=salmon, peripheries=2];
+	n4 [label="say", shape=box];
+	n5 [label="\"hi\"", shape=box];
+	n6 [label="(trailing slash)\nGET", shape=box, peripheries=2];
+	n7 [label="users\nGET POST", shape=box, peripheries=2];
+	n8 [label=":id\nGET\nname: users.show", shape=ellipse, style=filled, fillcolor=lightblue, peripheries=2];
+	n9 [label="?:page\nGET", shape=ellipse, style="filled,dashed", fillcolor=lightblue, peripheries=2];
+	n0 -> n1 [label="children", color=black];
+	n0 -> n4 [label="children", color=black];
+	n0 -> n7 [label="children", color=black];
+	n1 -> n2 [label="varyChildren", color=red, style=dashed];
+	n1 -> n3 [label="varyChildren", color=red, style=dashed];
+	n4 -> n5 [label="children", color=black];
+	n5 -> n6 [label="children", color=black];
+	n7 -> n8 [label="segChildren", color=blue];
+	n7 -> n9 [label="segChildren", color=blue];
+	n7 -> n9 [label="optionChildren", color=blue, style=dotted];
+}
+`
+	if b.String() != expected {
+		t.Fatalf("should write\n%s\ngot\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,49 @@
+package mux
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidMethod is returned when a route is registered with an empty
+// method or a method which is not a valid HTTP token.
+var ErrInvalidMethod = errors.New("mux: invalid method")
+
+// ErrInvalidPattern is returned when a routing pattern can not be parsed.
+type ErrInvalidPattern struct {
+	// Pattern is the pattern as it was registered.
+	Pattern string
+
+	// Column is the 1-based byte offset in Pattern where the problem was found.
+	Column int
+
+	// Reason describes what is wrong with the pattern.
+	Reason string
+}
+
+func (e *ErrInvalidPattern) Error() string {
+	return fmt.Sprintf("mux: invalid pattern %q at column %d: %s", e.Pattern, e.Column, e.Reason)
+}
+
+// ErrDuplicateRoute is returned when a handler is already defined
+// for the method on the pattern.
+type ErrDuplicateRoute struct {
+	Pattern string
+	Method  string
+}
+
+func (e *ErrDuplicateRoute) Error() string {
+	return fmt.Sprintf("mux: %s %q already defined", e.Method, e.Pattern)
+}
+
+// ErrDuplicateName is returned when a route name is already used by another route.
+type ErrDuplicateName struct {
+	Name string
+
+	// Pattern is the pattern of the route which owns the name.
+	Pattern string
+}
+
+func (e *ErrDuplicateName) Error() string {
+	return fmt.Sprintf("mux: route name %q already used by %q", e.Name, e.Pattern)
+}
@@ -0,0 +1,177 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Explanation records the steps of Trie.Match for a path, see Trie.Explain.
+type Explanation struct {
+	// Path is the explained path.
+	Path string `json:"path"`
+
+	Steps []ExplainStep `json:"steps"`
+
+	// Matched and Err are the result of Trie.Match.
+	Matched *Matched `json:"-"`
+	Err     error    `json:"-"`
+}
+
+// ExplainStep is a step of matching a path.
+type ExplainStep struct {
+	// Action is one of:
+	//  clean, lowercase  the path was cleaned or lowercased, Segment is the new path
+	//  match             Node matches Segment
+	//  skip              Node does not match Segment, see Reason
+	//  suffix            Segment without a suffix extension is tried again
+	//  backtrack         a wildcard tries to end before Segment
+	//  wildcard          Segment is the value of the wildcard Node
+	//  redirect          the path is redirected with or without trailing slash
+	//  endpoint          Node is the matched route
+	//  not found         no route matches
+	Action string `json:"action"`
+
+	Segment string `json:"segment,omitempty"`
+
+	// Node is the path of the node in the trie, e.g. "/users/:id".
+	Node string `json:"node,omitempty"`
+
+	Kind   NodeKind `json:"kind,omitempty"`
+	Reason string   `json:"reason"`
+}
+
+func (s ExplainStep) String() string {
+	var b bytes.Buffer
+	b.WriteString(s.Action)
+	if s.Segment != "" || s.Action == "match" || s.Action == "skip" {
+		fmt.Fprintf(&b, " %q", s.Segment)
+	}
+	if s.Node != "" {
+		fmt.Fprintf(&b, " %s %s", s.Kind, s.Node)
+	}
+	b.WriteString(": ")
+	b.WriteString(s.Reason)
+	return b.String()
+}
+
+// step records a step, it does nothing if x is nil.
+func (x *Explanation) step(action, segment string, node *Node, reason string) {
+	if x == nil {
+		return
+	}
+	s := ExplainStep{Action: action, Segment: segment, Reason: reason}
+	if node != nil {
+		s.Node, s.Kind = node.getSegments(), node.Kind()
+		if s.Node == "" {
+			s.Node = "/"
+		}
+	}
+	x.Steps = append(x.Steps, s)
+}
+
+// String returns the steps and the result, one per line.
+func (x *Explanation) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "path %q\n", x.Path)
+	for i, s := range x.Steps {
+		fmt.Fprintf(&b, "%3d. %s\n", i+1, s)
+	}
+	b.WriteString(x.result())
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func (x *Explanation) result() string {
+	switch {
+	case x.Err != nil:
+		return "error: " + x.Err.Error()
+	case x.Matched.Node != nil:
+		return fmt.Sprintf("matched %q, params %v", x.Matched.Node.pattern, x.Matched.Params)
+	case x.Matched.Path != "":
+		return fmt.Sprintf("redirect to %q", x.Matched.Path)
+	}
+	return "not found"
+}
+
+// Explain matches the path like Match and records every step: the children
+// tried for each segment and why they are skipped, wildcard backtracking,
+// suffix extension retries and trailing slash redirects.
+//
+//  fmt.Print(trie.Explain("/users/abc"))
+//  // path "/users/abc"
+//  //   1. match "users" static /users: static child
+//  //   2. skip "abc" regexp /users/:id:int: the segment does not match the regexp
+//  //   3. not found "abc" static /users: no child matches the segment
+//  // not found
+func (t *Trie) Explain(path string) *Explanation {
+	x := &Explanation{Path: path}
+	x.Matched, x.Err = t.match(path, x)
+	return x
+}
+
+// Explain explains how the path is matched, see Trie.Explain.
+func (m *Mux) Explain(path string) *Explanation {
+	return m.trie.Explain(path)
+}
+
+// ExplainHandler returns a debug handler explaining how the path in the
+// "path" query parameter is matched, and for the "method" query parameter
+// whether it is allowed. It writes text, or JSON with "format=json".
+// It should only be exposed to trusted clients.
+//
+//  mx.Handler("GET", "/debug/mux/explain", mx.ExplainHandler())
+//  // GET /debug/mux/explain?path=/users/abc&method=GET
+func (m *Mux) ExplainHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		path := q.Get("path")
+		if path == "" {
+			http.Error(w, `"path" query parameter is required`, http.StatusBadRequest)
+			return
+		}
+		x := m.Explain(path)
+		method := strings.ToUpper(q.Get("method"))
+		var allowed string
+		if x.Err == nil && x.Matched.Node != nil {
+			allow := append([]string(nil), x.Matched.Node.GetAllow()...)
+			sort.Strings(allow)
+			allowed = strings.Join(allow, ", ")
+			if x.Matched.Node.Handles(MethodAny) {
+				allowed = strings.TrimPrefix(allowed+", *", ", ")
+			}
+		}
+
+		if q.Get("format") == "json" {
+			v := struct {
+				*Explanation
+				Result  string `json:"result"`
+				Pattern string `json:"pattern,omitempty"`
+				Allowed string `json:"allowed,omitempty"`
+				Handled *bool  `json:"handled,omitempty"`
+			}{Explanation: x, Result: x.result(), Allowed: allowed}
+			if x.Err == nil && x.Matched.Node != nil {
+				v.Pattern = x.Matched.Node.pattern
+				if method != "" {
+					handled := x.Matched.Node.Handles(method)
+					v.Handled = &handled
+				}
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			json.NewEncoder(w).Encode(v)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, x)
+		if allowed != "" {
+			fmt.Fprintf(w, "methods: %s\n", allowed)
+			if method != "" && !x.Matched.Node.Handles(method) {
+				fmt.Fprintf(w, "%s is not allowed\n", method)
+			}
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package mux
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	trie := NewTrie()
+	trie.Parse("/users/:id:int").Handle("GET", "show")
+	trie.Parse("/users/new").Handle("GET", "new")
+	trie.Parse("/files/*/raw").Handle("GET", "raw")
+	trie.Parse("/docs/").Handle("GET", "docs")
+	trie.Parse("/api/:version/status").Handle("GET", "status")
+
+	items := []struct {
+		path, expected string
+	}{
+		{"/users/abc", `path "/users/abc"
+  1. match "users" static /users: static child
+  2. skip "abc" regexp /users/:id:int: the segment does not match the regexp
+  3. not found "abc" static /users: no child matches the segment
+not found
+`},
+		{"/users/new.json", `path "/users/new.json"
+  1. match "users" static /users: static child
+  2. skip "new.json" regexp /users/:id:int: the segment does not match the regexp
+  3. suffix "new" static /users: retry without the extension
+  4. match "new" static /users/new: static child
+  5. endpoint static /users/new: matched the route
+matched "/users/new", params map[:ext:json]
+`},
+		{"/files/a/b/raw", `path "/files/a/b/raw"
+  1. match "files" static /files: static child
+  2. match "a" wildcard /files/*: wildcard
+  3. backtrack "b" wildcard /files/*: try to end the wildcard before the segment
+  4. backtrack "raw" wildcard /files/*: try to end the wildcard before the segment
+  5. match "raw" static /files/*/raw: static child
+  6. wildcard "a/b" wildcard /files/*: wildcard ends, the next segment matches a child
+  7. endpoint static /files/*/raw: matched the route
+matched "/files/*/raw", params map[:splat:a/b]
+`},
+		{"/docs", `path "/docs"
+  1. match "docs" static /docs: static child
+  2. redirect static /docs: no route, redirect to the path with trailing slash
+redirect to "/docs/"
+`},
+		{"/api/v1", `path "/api/v1"
+  1. match "api" static /api: static child
+  2. skip "v1" param /api/:version: the path ends but the node has no handler
+  3. not found "v1" static /api: no child matches the segment
+not found
+`},
+		{"api", `path "api"
+error: path is not start with "/": "api"
+`},
+	}
+	for _, v := range items {
+		if s := trie.Explain(v.path).String(); s != v.expected {
+			t.Fatalf("%s: should explain\n%s\ngot\n%s", v.path, v.expected, s)
+		}
+	}
+
+	x := trie.Explain("/users/12")
+	m, _ := trie.Match("/users/12")
+	if x.Matched.Node != m.Node || x.Matched.Params[":id"] != "12" {
+		t.Fatalf("should return the result of Match, got %+v", x.Matched)
+	}
+}
+
+func TestMatchAllocs(t *testing.T) {
+	trie := NewTrie()
+	trie.Parse("/users/:id:int").Handle("GET", "show")
+	trie.Parse("/users/new").Handle("GET", "new")
+	allocs := testing.AllocsPerRun(100, func() { trie.Match("/users/new") })
+	if allocs > 1 {
+		t.Fatalf("Match should not allocate for the explanation, got %v allocs", allocs)
+	}
+}
+
+func TestExplainHandler(t *testing.T) {
+	mx := New()
+	mx.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	mx.Put("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	mx.Handler("GET", "/debug/mux/explain", mx.ExplainHandler())
+
+	w := httptest.NewRecorder()
+	mx.ServeHTTP(w, httptest.NewRequest("GET", "/debug/mux/explain?path=/users/1&method=delete", nil))
+	expected := `path "/users/1"
+  1. match "users" static /users: static child
+  2. match "1" param /users/:id: param
+  3. endpoint param /users/:id: matched the route
+matched "/users/:id", params map[:id:1]
+methods: GET, PUT
+DELETE is not allowed
+`
+	if w.Body.String() != expected {
+		t.Fatalf("should explain\n%s\ngot\n%s", expected, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mx.ServeHTTP(w, httptest.NewRequest("GET", "/debug/mux/explain?path=/users/1&method=put&format=json", nil))
+	var v struct {
+		Path    string
+		Steps   []ExplainStep
+		Result  string
+		Pattern string
+		Allowed string
+		Handled bool
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Path != "/users/1" || len(v.Steps) != 3 || v.Steps[1].Kind != KindParam || v.Pattern != "/users/:id" ||
+		v.Allowed != "GET, PUT" || !v.Handled || !strings.HasPrefix(v.Result, "matched") {
+		t.Fatalf("wrong JSON explanation %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mx.ServeHTTP(w, httptest.NewRequest("GET", "/debug/mux/explain", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("should require the path, got %d", w.Code)
+	}
+}
@@ -0,0 +1,256 @@
+package mux
+
+import (
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileOptions describes options for ServeFiles.
+type FileOptions struct {
+	// Index is the file served for a directory, "index.html" if empty.
+	Index string
+
+	// Listing lists the files of a directory without index file,
+	// otherwise such directory is not found.
+	Listing bool
+
+	// SPA serves the index file of the root directory for the paths not found,
+	// so that a single page application can route them.
+	SPA bool
+
+	// Precompressed serves the ".br" or ".gz" variant of a file when it exists
+	// and the request accepts its encoding, with the content type of the file.
+	Precompressed bool
+
+	// Immutable serves fingerprinted files, whose name has a hash of at least
+	// 8 hexadecimal digits such as "app.3f2a9c1d.js", with a Cache-Control
+	// header to cache them for one year.
+	Immutable bool
+}
+
+// fingerprintRegexp matches the names of fingerprinted files.
+var fingerprintRegexp = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[^/]+$`)
+
+// encodings are the precompressed variants in order of preference.
+var encodings = []struct{ name, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// ServeFiles serves files from the file system with the path matched by the
+// wildcard which ends the pattern, for GET and HEAD requests.
+// The directory of the wildcard is served as well, e.g. "/static/" for
+// "/static/*filepath". It panics if the pattern does not end with a wildcard.
+//
+// Paths containing ".." segments are rejected. Responses have ETag and
+// Last-Modified headers and support conditional and range requests.
+//
+//  mx.ServeFiles("/static/*filepath", http.Dir("public"))
+//  mx.ServeFiles("/*", http.Dir("dist"), mux.FileOptions{SPA: true})
+func (m *Mux) ServeFiles(pattern string, fs http.FileSystem, opts ...FileOptions) {
+	h := &fileHandler{fs: fs}
+	if len(opts) > 0 {
+		h.opts = opts[0]
+	}
+	if h.opts.Index == "" {
+		h.opts.Index = "index.html"
+	}
+	i := strings.LastIndexByte(pattern, '/')
+	if i < 0 || !isWildcardSegment(pattern[i+1:]) {
+		panic(&ErrInvalidPattern{Pattern: pattern, Column: i + 2, Reason: "ServeFiles needs a pattern ending with a wildcard"})
+	}
+	h.wildcard = pattern[i+1:]
+	for _, p := range []string{pattern, pattern[:i+1]} {
+		m.Get(p, h.ServeHTTP)
+		m.Head(p, h.ServeHTTP)
+	}
+}
+
+type fileHandler struct {
+	fs       http.FileSystem
+	opts     FileOptions
+	wildcard string
+}
+
+// filePath returns the path matched by the wildcard.
+func (h *fileHandler) filePath(req *http.Request) string {
+	switch h.wildcard {
+	case "*":
+		return Param(req, ":splat")
+	case "*.*":
+		if p := Param(req, ":path"); p != "" {
+			return p + "." + Param(req, ":ext")
+		}
+		return ""
+	}
+	return Param(req, ":"+h.wildcard[1:])
+}
+
+func (h *fileHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := h.filePath(req)
+	if strings.Contains(name, "\x00") || containsDotDot(name) {
+		http.Error(w, "invalid URL path", http.StatusBadRequest)
+		return
+	}
+	h.serveFile(w, req, path.Clean("/"+name), true)
+}
+
+func (h *fileHandler) serveFile(w http.ResponseWriter, req *http.Request, name string, fallback bool) {
+	f, err := h.fs.Open(name)
+	if err != nil {
+		h.serveError(w, req, err, fallback)
+		return
+	}
+	defer f.Close()
+	d, err := f.Stat()
+	if err != nil {
+		h.serveError(w, req, err, fallback)
+		return
+	}
+
+	if d.IsDir() {
+		if !strings.HasSuffix(req.URL.Path, "/") {
+			u := *req.URL
+			u.Path += "/"
+			http.Redirect(w, req, u.String(), http.StatusMovedPermanently)
+			return
+		}
+		index := strings.TrimSuffix(name, "/") + "/" + h.opts.Index
+		if fi, err := h.fs.Open(index); err == nil {
+			fi.Close()
+			h.serveFile(w, req, index, fallback)
+			return
+		}
+		if !h.opts.Listing {
+			h.serveError(w, req, os.ErrNotExist, fallback)
+			return
+		}
+		h.serveListing(w, f)
+		return
+	}
+
+	if h.opts.Immutable && fingerprintRegexp.MatchString(name) {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	if h.opts.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if h.serveEncoded(w, req, name) {
+			return
+		}
+	}
+	if w.Header().Get("ETag") == "" {
+		w.Header().Set("ETag", fmt.Sprintf(`W/"%x-%x"`, d.ModTime().UnixNano(), d.Size()))
+	}
+	http.ServeContent(w, req, d.Name(), d.ModTime(), f)
+}
+
+// serveEncoded serves the precompressed variant of the file accepted by
+// the request, it reports whether there is one.
+func (h *fileHandler) serveEncoded(w http.ResponseWriter, req *http.Request, name string) bool {
+	accepted := acceptedEncodings(req.Header.Get("Accept-Encoding"))
+	for _, e := range encodings {
+		if !accepted[e.name] && !accepted["*"] {
+			continue
+		}
+		f, err := h.fs.Open(name + e.ext)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		d, err := f.Stat()
+		if err != nil || d.IsDir() {
+			continue
+		}
+		ctype := mime.TypeByExtension(path.Ext(name))
+		if ctype == "" {
+			ctype = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", ctype)
+		w.Header().Set("Content-Encoding", e.name)
+		w.Header().Set("ETag", fmt.Sprintf(`W/"%x-%x-%s"`, d.ModTime().UnixNano(), d.Size(), e.name))
+		http.ServeContent(w, req, path.Base(name), d.ModTime(), f)
+		return true
+	}
+	return false
+}
+
+// acceptedEncodings returns the encodings of an Accept-Encoding header
+// which are not refused with a zero quality.
+func acceptedEncodings(header string) map[string]bool {
+	accepted := make(map[string]bool)
+	for _, v := range strings.Split(header, ",") {
+		coding, params, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			continue
+		}
+		accepted[coding] = true
+	}
+	return accepted
+}
+
+// serveError responds to a file system error, serving the index of the root
+// directory instead of not found in SPA mode.
+func (h *fileHandler) serveError(w http.ResponseWriter, req *http.Request, err error, fallback bool) {
+	switch {
+	case os.IsNotExist(err):
+		if h.opts.SPA && fallback {
+			h.serveFile(w, req, "/"+h.opts.Index, false)
+			return
+		}
+		http.Error(w, "404 page not found", http.StatusNotFound)
+	case os.IsPermission(err):
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+func (h *fileHandler) serveListing(w http.ResponseWriter, f http.File) {
+	files, err := f.Readdir(-1)
+	if err != nil {
+		http.Error(w, "Error reading directory", http.StatusInternalServerError)
+		return
+	}
+	names := make([]string, 0, len(files))
+	for _, d := range files {
+		name := d.Name()
+		if d.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<pre>\n")
+	for _, name := range names {
+		u := url.URL{Path: name}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", html.EscapeString(u.String()), html.EscapeString(name))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
+
+// containsDotDot reports whether the path has a ".." segment.
+func containsDotDot(p string) bool {
+	if !strings.Contains(p, "..") {
+		return false
+	}
+	for _, s := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if s == ".." {
+			return true
+		}
+	}
+	return false
+}
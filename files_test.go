@@ -0,0 +1,128 @@
+package mux
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newFileDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "mux-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"index.html":      "root index",
+		"app.js":          "console.log(1)",
+		"docs/index.html": "docs index",
+		"list/a.txt":      "a",
+		"list/b.txt":      "b",
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "list", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func serveFile(mx *Mux, method, path string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	mx.ServeHTTP(w, req)
+	return w
+}
+
+func TestServeFiles(t *testing.T) {
+	dir := newFileDir(t)
+	defer os.RemoveAll(dir)
+
+	mx := New()
+	mx.ServeFiles("/static/*filepath", http.Dir(dir), FileOptions{Listing: true})
+
+	items := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/static/app.js", 200, "console.log(1)"},
+		{"HEAD", "/static/app.js", 200, ""},
+		{"GET", "/static/", 200, "root index"},
+		{"GET", "/static/docs/", 200, "docs index"},
+		{"GET", "/static/docs/index.html", 200, "docs index"},
+		{"GET", "/static/docs", 301, ""},
+		{"GET", "/static/list/", 200, "<pre>\n<a href=\"a.txt\">a.txt</a>\n<a href=\"b.txt\">b.txt</a>\n<a href=\"sub/\">sub/</a>\n</pre>\n"},
+		{"GET", "/static/missing.js", 404, "404 page not found\n"},
+		{"GET", "/static/list/../../secret", 400, "invalid URL path\n"},
+		{"POST", "/static/app.js", 405, ""},
+	}
+	for _, v := range items {
+		w := serveFile(mx, v.method, v.path)
+		if w.Code != v.code {
+			t.Fatalf("%s %s: should return %d, got %d", v.method, v.path, v.code, w.Code)
+		}
+		if v.body != "" && w.Body.String() != v.body {
+			t.Fatalf("%s %s: should return %q, got %q", v.method, v.path, v.body, w.Body.String())
+		}
+	}
+
+	w := serveFile(mx, "GET", "/static/app.js")
+	etag := w.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) || w.Header().Get("Last-Modified") == "" {
+		t.Fatalf("should set ETag and Last-Modified, got %q, %q", etag, w.Header().Get("Last-Modified"))
+	}
+	if w = serveFile(mx, "GET", "/static/app.js", "If-None-Match", etag); w.Code != 304 {
+		t.Fatalf("should return 304 for a matching ETag, got %d", w.Code)
+	}
+	if w = serveFile(mx, "GET", "/static/app.js", "Range", "bytes=0-6"); w.Code != 206 || w.Body.String() != "console" {
+		t.Fatalf("should serve a range, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestServeFilesSPA(t *testing.T) {
+	dir := newFileDir(t)
+	defer os.RemoveAll(dir)
+
+	mx := New()
+	mx.ServeFiles("/*", http.Dir(dir), FileOptions{SPA: true})
+
+	items := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/", 200, "root index"},
+		{"/app.js", 200, "console.log(1)"},
+		{"/users/123", 200, "root index"},
+		{"/list/", 200, "root index"},
+	}
+	for _, v := range items {
+		w := serveFile(mx, "GET", v.path)
+		if w.Code != v.code || w.Body.String() != v.body {
+			t.Fatalf("%s: should return %d %q, got %d %q", v.path, v.code, v.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestServeFilesPattern(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*ErrInvalidPattern); !ok {
+			t.Fatal("should panic with *ErrInvalidPattern")
+		}
+	}()
+	New().ServeFiles("/static/:file", http.Dir("."))
+}
@@ -0,0 +1,21 @@
+//go:build go1.16
+// +build go1.16
+
+package mux
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+// ServeFS is like ServeFiles but serves files from an fs.FS, such as an
+// embed.FS, so that a single binary can serve its assets.
+//
+//  //go:embed dist
+//  var dist embed.FS
+//
+//  sub, _ := fs.Sub(dist, "dist")
+//  mx.ServeFS("/assets/*filepath", sub, mux.FileOptions{Precompressed: true, Immutable: true})
+func (m *Mux) ServeFS(pattern string, fsys fs.FS, opts ...FileOptions) {
+	m.ServeFiles(pattern, http.FS(fsys), opts...)
+}
@@ -0,0 +1,62 @@
+//go:build go1.16
+// +build go1.16
+
+package mux
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestServeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":           {Data: []byte("index")},
+		"app.3f2a9c1d.js":      {Data: []byte("app")},
+		"app.3f2a9c1d.js.br":   {Data: []byte("app br")},
+		"app.3f2a9c1d.js.gz":   {Data: []byte("app gz")},
+		"style.css":            {Data: []byte("style")},
+		"style.css.gz":         {Data: []byte("style gz")},
+		"images/logo.svg":      {Data: []byte("<svg/>")},
+		"images/logo.svg.gz/x": {Data: []byte("not a file")},
+	}
+	mx := New()
+	mx.ServeFS("/assets/*filepath", fsys, FileOptions{Precompressed: true, Immutable: true})
+
+	items := []struct {
+		path, acceptEncoding string
+		body, encoding       string
+		contentType, cache   string
+	}{
+		{"/assets/", "", "index", "", "text/html; charset=utf-8", ""},
+		{"/assets/app.3f2a9c1d.js", "", "app", "", "text/javascript; charset=utf-8", "public, max-age=31536000, immutable"},
+		{"/assets/app.3f2a9c1d.js", "gzip, br", "app br", "br", "text/javascript; charset=utf-8", "public, max-age=31536000, immutable"},
+		{"/assets/app.3f2a9c1d.js", "gzip, br;q=0", "app gz", "gzip", "text/javascript; charset=utf-8", "public, max-age=31536000, immutable"},
+		{"/assets/app.3f2a9c1d.js", "*", "app br", "br", "text/javascript; charset=utf-8", "public, max-age=31536000, immutable"},
+		{"/assets/style.css", "br", "style", "", "text/css; charset=utf-8", ""},
+		{"/assets/style.css", "gzip", "style gz", "gzip", "text/css; charset=utf-8", ""},
+		{"/assets/images/logo.svg", "gzip", "<svg/>", "", "image/svg+xml", ""},
+	}
+	for _, v := range items {
+		w := serveFile(mx, "GET", v.path, "Accept-Encoding", v.acceptEncoding)
+		if w.Code != 200 || w.Body.String() != v.body {
+			t.Fatalf("%s %q: should return %q, got %d %q", v.path, v.acceptEncoding, v.body, w.Code, w.Body.String())
+		}
+		h := w.Header()
+		if h.Get("Content-Encoding") != v.encoding || h.Get("Content-Type") != v.contentType || h.Get("Cache-Control") != v.cache {
+			t.Fatalf("%s %q: should return %q, %q, %q, got %q, %q, %q", v.path, v.acceptEncoding,
+				v.encoding, v.contentType, v.cache, h.Get("Content-Encoding"), h.Get("Content-Type"), h.Get("Cache-Control"))
+		}
+		if v.path != "/assets/" && h.Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("%s: should vary on Accept-Encoding, got %q", v.path, h.Get("Vary"))
+		}
+	}
+
+	w := serveFile(mx, "GET", "/assets/app.3f2a9c1d.js", "Accept-Encoding", "gzip")
+	etag := w.Header().Get("ETag")
+	if w = serveFile(mx, "GET", "/assets/app.3f2a9c1d.js", "Accept-Encoding", "gzip", "If-None-Match", etag); w.Code != 304 {
+		t.Fatalf("should return 304 for a matching ETag, got %d", w.Code)
+	}
+	if w = serveFile(mx, "GET", "/assets/app.3f2a9c1d.js", "If-None-Match", etag); w.Code != 200 {
+		t.Fatalf("should not match the ETag of another encoding, got %d", w.Code)
+	}
+}
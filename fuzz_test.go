@@ -0,0 +1,108 @@
+//go:build go1.18
+// +build go1.18
+
+package mux
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzParse(f *testing.F) {
+	for _, pattern := range []string{
+		"/", "/a/b/", "/:id", "/?:id", "/:id:int", "/:name:string", "/:id([0-9]+)",
+		"/cms_:id([0-9]+).html", "/:a-:b", "/*", "/*.*", "/*path", "/a::b", "/:id(", "/a//b",
+		"/:id([0-9]+)_:name:string", "/?:id:int", "/:(x)", "/:id:int:int", `\Q:`,
+	} {
+		f.Add(pattern, "/a/1")
+	}
+	f.Fuzz(func(t *testing.T, pattern, path string) {
+		trie := NewTrie()
+		node, err := trie.TryParse(pattern)
+		if err != nil {
+			if _, ok := err.(*ErrInvalidPattern); !ok {
+				t.Fatalf("%q: should return *ErrInvalidPattern, got %T %v", pattern, err, err)
+			}
+			return
+		}
+		if err := node.TryHandle("GET", "handler"); err != nil {
+			t.Fatalf("%q: should handle, got %v", pattern, err)
+		}
+		// parsing the pattern again returns the same node
+		if again, err := trie.TryParse(pattern); err != nil || again != node {
+			t.Fatalf("%q: should parse to the same node, got %v", pattern, err)
+		}
+		if !strings.HasPrefix(path, "/") {
+			return
+		}
+		matched, err := trie.Match(path)
+		if err != nil {
+			return
+		}
+		checkParams(t, path, matched)
+	})
+}
+
+func FuzzMatch(f *testing.F) {
+	trie := NewTrie()
+	// alone has a trie of each route by itself
+	alone := make(map[string]*Trie)
+	configs, paths := loadMatchCorpus(f)
+	for _, c := range configs {
+		trie.Parse(c.Pattern).Priority(c.Priority).Handle(c.Method, c.Pattern)
+		alone[c.Pattern] = NewTrie()
+		alone[c.Pattern].Parse(c.Pattern).Handle(c.Method, c.Pattern)
+	}
+	for _, path := range paths {
+		f.Add(path)
+	}
+	f.Fuzz(func(t *testing.T, path string) {
+		if !strings.HasPrefix(path, "/") {
+			return
+		}
+		matched, err := trie.Match(path)
+		if err != nil || matched.Node == nil {
+			return
+		}
+		checkParams(t, path, matched)
+
+		// the URL built with the params is matched by the route. Another
+		// route can win it in the trie, as the static /r/a wins /r/a/ of
+		// /r/:x([a-c]+)/?:opt built from /r/aA/, so the URL is matched by
+		// the route alone
+		var pairs []string
+		for k, v := range matched.Params {
+			if k == ":ext" && !strings.Contains(matched.Node.pattern, "*.*") {
+				// suffix extension, not a param of the pattern
+				continue
+			}
+			pairs = append(pairs, k, v)
+		}
+		u, err := matched.Node.BuildURL(pairs...)
+		if err != nil {
+			t.Fatalf("%q: should build the URL of %q with %v, got %v", path, matched.Node.pattern, pairs, err)
+		}
+		again, err := alone[matched.Node.pattern].Match(u.Path)
+		if err != nil || again.Node == nil || again.Node.pattern != matched.Node.pattern {
+			t.Fatalf("%q: built %q which should match %q, got %+v %v", path, u.Path, matched.Node.pattern, again, err)
+		}
+	})
+}
+
+// checkParams checks that the params are names of the matched pattern.
+func checkParams(t *testing.T, path string, matched *Matched) {
+	if matched.Node == nil {
+		return
+	}
+	names := map[string]bool{":ext": true}
+	for n := matched.Node; n != nil; n = n.parent {
+		for _, name := range n.name {
+			names[name] = true
+		}
+	}
+	for k := range matched.Params {
+		if !names[k] {
+			t.Fatalf("%q: param %q is not a name of %q", path, k, matched.Node.pattern)
+		}
+	}
+}
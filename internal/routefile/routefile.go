@@ -0,0 +1,48 @@
+// Package routefile loads route table files for the commands of mux, which
+// have no handlers or middleware of their own.
+package routefile
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/beego/mux"
+)
+
+// Load parses the route table file with mux.ParseRoutes and loads it in a Mux
+// with the default options and placeholder handlers and middleware, patterns
+// in brace syntax if brace is true. It returns the Mux and the routes of the
+// file, disabled ones included.
+func Load(filename string, brace bool) (*mux.Mux, []mux.RouteConfig, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	configs, err := mux.ParseRoutes(f, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registry := mux.Registry{
+		Handlers:   make(map[string]http.HandlerFunc),
+		Middleware: make(map[string]mux.Middleware),
+	}
+	for _, c := range configs {
+		registry.Handlers[c.Handler] = func(http.ResponseWriter, *http.Request) {}
+		for _, m := range c.Middleware {
+			registry.Middleware[m] = func(h http.HandlerFunc) http.HandlerFunc { return h }
+		}
+	}
+	mx := mux.New(mux.Options{
+		CaseSensitive:  true,
+		PathClean:      true,
+		StrictSlash:    true,
+		UseEncodedPath: true,
+		BraceSyntax:    brace,
+	})
+	if err := mx.LoadRoutes(configs, registry); err != nil {
+		return nil, nil, err
+	}
+	return mx, configs, nil
+}
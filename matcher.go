@@ -0,0 +1,274 @@
+package mux
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Matcher checks a request beyond its path and method, see Mux.HandleWith.
+type Matcher interface {
+	// Match reports whether the request matches, when it does not the status
+	// is the code to respond if no other handler matches.
+	Match(req *http.Request) (ok bool, status int)
+}
+
+// MatcherFunc is an adapter to allow the use of ordinary functions as Matcher.
+type MatcherFunc func(req *http.Request) (ok bool, status int)
+
+// Match calls f(req).
+func (f MatcherFunc) Match(req *http.Request) (bool, int) {
+	return f(req)
+}
+
+// describedMatcher is a matcher of this package, matchers with the same
+// description are the same, see sameMatchers.
+type describedMatcher struct {
+	MatcherFunc
+	desc string
+}
+
+func (m describedMatcher) String() string {
+	return m.desc
+}
+
+// Header matches requests with the header, or with one of the values of the
+// header equal to the value if the value is not empty.
+// Otherwise the status is 404.
+func Header(key, value string) Matcher {
+	return describedMatcher{desc: fmt.Sprintf("Header(%q, %q)", key, value), MatcherFunc: func(req *http.Request) (bool, int) {
+		v, ok := req.Header[http.CanonicalHeaderKey(key)]
+		if !ok || value != "" && !anyValue(v, func(s string) bool { return s == value }) {
+			return false, http.StatusNotFound
+		}
+		return true, 0
+	}}
+}
+
+// HeaderRegexp matches requests with one of the values of the header
+// matching the regexp. Otherwise the status is 404.
+func HeaderRegexp(key, expr string) Matcher {
+	r := regexp.MustCompile(expr)
+	return describedMatcher{desc: fmt.Sprintf("HeaderRegexp(%q, %q)", key, expr), MatcherFunc: func(req *http.Request) (bool, int) {
+		if !anyValue(req.Header[http.CanonicalHeaderKey(key)], r.MatchString) {
+			return false, http.StatusNotFound
+		}
+		return true, 0
+	}}
+}
+
+// Query matches requests with the query parameter, or with one of the values
+// of the query parameter equal to the value if the value is not empty.
+// Otherwise the status is 404.
+func Query(key, value string) Matcher {
+	return describedMatcher{desc: fmt.Sprintf("Query(%q, %q)", key, value), MatcherFunc: func(req *http.Request) (bool, int) {
+		v, ok := req.URL.Query()[key]
+		if !ok || value != "" && !anyValue(v, func(s string) bool { return s == value }) {
+			return false, http.StatusNotFound
+		}
+		return true, 0
+	}}
+}
+
+// QueryRegexp matches requests with one of the values of the query parameter
+// matching the regexp. Otherwise the status is 404.
+func QueryRegexp(key, expr string) Matcher {
+	r := regexp.MustCompile(expr)
+	return describedMatcher{desc: fmt.Sprintf("QueryRegexp(%q, %q)", key, expr), MatcherFunc: func(req *http.Request) (bool, int) {
+		if !anyValue(req.URL.Query()[key], r.MatchString) {
+			return false, http.StatusNotFound
+		}
+		return true, 0
+	}}
+}
+
+// anyValue reports whether one of the values satisfies fn.
+func anyValue(values []string, fn func(string) bool) bool {
+	for _, v := range values {
+		if fn(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scheme matches requests with one of the schemes, "http" or "https".
+// Otherwise the status is 404.
+func Scheme(schemes ...string) Matcher {
+	return describedMatcher{desc: fmt.Sprintf("Scheme(%q)", schemes), MatcherFunc: func(req *http.Request) (bool, int) {
+		scheme := req.URL.Scheme
+		if scheme == "" {
+			scheme = "http"
+			if req.TLS != nil {
+				scheme = "https"
+			}
+		}
+		for _, s := range schemes {
+			if strings.EqualFold(s, scheme) {
+				return true, 0
+			}
+		}
+		return false, http.StatusNotFound
+	}}
+}
+
+// Accept matches requests accepting one of the media types, or without
+// Accept header. Otherwise the status is 406.
+func Accept(mediaTypes ...string) Matcher {
+	return describedMatcher{desc: fmt.Sprintf("Accept(%q)", mediaTypes), MatcherFunc: func(req *http.Request) (bool, int) {
+		accept := req.Header.Get("Accept")
+		if accept == "" {
+			return true, 0
+		}
+		for _, r := range strings.Split(accept, ",") {
+			mediaRange, params, err := mime.ParseMediaType(r)
+			if err != nil || params["q"] == "0" || params["q"] == "0.0" {
+				continue
+			}
+			for _, t := range mediaTypes {
+				if matchMediaRange(mediaRange, t) {
+					return true, 0
+				}
+			}
+		}
+		return false, http.StatusNotAcceptable
+	}}
+}
+
+// ContentType matches requests with a body of one of the media types.
+// Otherwise the status is 415.
+func ContentType(mediaTypes ...string) Matcher {
+	return describedMatcher{desc: fmt.Sprintf("ContentType(%q)", mediaTypes), MatcherFunc: func(req *http.Request) (bool, int) {
+		mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err == nil {
+			for _, t := range mediaTypes {
+				if strings.EqualFold(mediaType, t) {
+					return true, 0
+				}
+			}
+		}
+		return false, http.StatusUnsupportedMediaType
+	}}
+}
+
+// matchMediaRange reports whether the media type is in the media range,
+// e.g. "text/*" contains "text/html".
+func matchMediaRange(mediaRange, mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	return strings.HasSuffix(mediaRange, "/*") &&
+		strings.HasPrefix(mediaType, mediaRange[:len(mediaRange)-1])
+}
+
+type conditionalRoute struct {
+	matchers []Matcher
+	handler  http.HandlerFunc
+}
+
+// handleWith adds the handler with matchers for the method to the node.
+// The handlers with matchers are kept apart from the handlers of Handle,
+// which stay the fallback when no matchers match.
+func (n *Node) handleWith(method string, handler http.HandlerFunc, matchers []Matcher) error {
+	routes := n.conditions[method]
+	for _, r := range routes {
+		if sameMatchers(r.matchers, matchers) {
+			return &ErrDuplicateRoute{Pattern: n.pattern, Method: method}
+		}
+	}
+	if n.conditions == nil {
+		n.conditions = make(map[string][]conditionalRoute)
+	}
+	if _, ok := n.handlers[method]; !ok && len(routes) == 0 && method != MethodAny {
+		n.allow = append(n.allow, method)
+	}
+	n.conditions[method] = append(routes, conditionalRoute{matchers: matchers, handler: handler})
+	return nil
+}
+
+// handlerFunc returns the handler of the method for the request, the handler
+// with matchers matching the request or else the handler without matchers.
+// When the method has only handlers with matchers and none matches, the
+// status is the one of the matchers.
+func (n *Node) handlerFunc(method string, req *http.Request) (http.HandlerFunc, int) {
+	method = n.handlerMethod(method)
+	status := 0
+	if routes := n.conditions[method]; routes != nil {
+		var handler http.HandlerFunc
+		if handler, status = matchConditions(routes, req); handler != nil {
+			return handler, 0
+		}
+	}
+	if h, ok := n.handlers[method].(http.HandlerFunc); ok {
+		return h, 0
+	}
+	return nil, status
+}
+
+// matchConditions returns the handler of the first route whose matchers all
+// match. Otherwise it returns the status of the rejections if they all agree,
+// 404 if not.
+func matchConditions(routes []conditionalRoute, req *http.Request) (http.HandlerFunc, int) {
+	status := 0
+	for _, r := range routes {
+		ok, s := r.match(req)
+		if ok {
+			return r.handler, 0
+		}
+		if status == 0 {
+			status = s
+		} else if status != s {
+			status = http.StatusNotFound
+		}
+	}
+	return nil, status
+}
+
+func (r conditionalRoute) match(req *http.Request) (bool, int) {
+	for _, m := range r.matchers {
+		if ok, status := m.Match(req); !ok {
+			if status == 0 {
+				status = http.StatusNotFound
+			}
+			return false, status
+		}
+	}
+	return true, 0
+}
+
+// sameMatchers reports whether a and b hold the same matchers in any order.
+// Matchers of this package are the same with the same arguments, other
+// matchers only when they are equal comparable values.
+func sameMatchers(a, b []Matcher) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	used := make([]bool, len(b))
+	for _, ma := range a {
+		found := false
+		for j, mb := range b {
+			if !used[j] && sameMatcher(ma, mb) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func sameMatcher(a, b Matcher) bool {
+	da, ok1 := a.(describedMatcher)
+	db, ok2 := b.(describedMatcher)
+	if ok1 || ok2 {
+		return ok1 && ok2 && da.desc == db.desc
+	}
+	ta := reflect.TypeOf(a)
+	return ta == reflect.TypeOf(b) && ta.Comparable() && a == b
+}
@@ -0,0 +1,134 @@
+package mux
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	items := []struct {
+		matcher Matcher
+		req     func(*http.Request)
+		ok      bool
+		status  int
+	}{
+		{Header("X-Version", ""), func(r *http.Request) { r.Header.Set("X-Version", "2") }, true, 0},
+		{Header("X-Version", "2"), func(r *http.Request) { r.Header.Set("X-Version", "1") }, false, 404},
+		{Header("X-Version", ""), func(r *http.Request) {}, false, 404},
+		{Header("X-Version", "2"), func(r *http.Request) { r.Header.Add("X-Version", "1"); r.Header.Add("X-Version", "2") }, true, 0},
+		{HeaderRegexp("X-Version", `^2$`), func(r *http.Request) { r.Header.Add("X-Version", "1"); r.Header.Add("X-Version", "2") }, true, 0},
+		{HeaderRegexp("X-Version", `^2$`), func(r *http.Request) {}, false, 404},
+		{HeaderRegexp("X-Version", `^[12]$`), func(r *http.Request) { r.Header.Set("X-Version", "2") }, true, 0},
+		{Query("page", ""), func(r *http.Request) { r.URL.RawQuery = "page=" }, true, 0},
+		{Query("page", "2"), func(r *http.Request) { r.URL.RawQuery = "page=3" }, false, 404},
+		{QueryRegexp("page", `^\d+$`), func(r *http.Request) { r.URL.RawQuery = "page=x" }, false, 404},
+		{Query("tag", "b"), func(r *http.Request) { r.URL.RawQuery = "tag=a&tag=b" }, true, 0},
+		{QueryRegexp("tag", `^b$`), func(r *http.Request) { r.URL.RawQuery = "tag=a&tag=b" }, true, 0},
+		{Scheme("https"), func(r *http.Request) { r.TLS = &tls.ConnectionState{} }, true, 0},
+		{Scheme("https"), func(r *http.Request) {}, false, 404},
+		{Accept("application/json"), func(r *http.Request) {}, true, 0},
+		{Accept("application/json"), func(r *http.Request) { r.Header.Set("Accept", "text/html, application/*;q=0.8") }, true, 0},
+		{Accept("application/json"), func(r *http.Request) { r.Header.Set("Accept", "*/*") }, true, 0},
+		{Accept("application/json"), func(r *http.Request) { r.Header.Set("Accept", "text/html, application/json;q=0") }, false, 406},
+		{ContentType("application/json"), func(r *http.Request) { r.Header.Set("Content-Type", "application/json; charset=utf-8") }, true, 0},
+		{ContentType("application/json"), func(r *http.Request) { r.Header.Set("Content-Type", "text/plain") }, false, 415},
+		{ContentType("application/json"), func(r *http.Request) {}, false, 415},
+	}
+	for i, v := range items {
+		req := httptest.NewRequest("GET", "/", nil)
+		v.req(req)
+		if ok, status := v.matcher.Match(req); ok != v.ok || status != v.status {
+			t.Fatalf("%d: should return %t, %d, got %t, %d", i, v.ok, v.status, ok, status)
+		}
+	}
+}
+
+func TestHandleWith(t *testing.T) {
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}
+	}
+	mux := New()
+	mux.HandleWith("POST", "/users", handler("json"), ContentType("application/json"))
+	mux.HandleWith("POST", "/users", handler("form"), ContentType("application/x-www-form-urlencoded"))
+	mux.HandleWith("GET", "/users", handler("v2"), Header("API-Version", "2"))
+	mux.Get("/users", handler("v1"))
+	mux.HandleWith("GET", "/users", handler("csv"), Accept("text/csv"), Query("format", "csv"))
+	mux.HandleWith("GET", "/reports", handler("csv"), Accept("text/csv"))
+
+	items := []struct {
+		method, path string
+		header       map[string]string
+		code         int
+		body         string
+	}{
+		{"POST", "/users", map[string]string{"Content-Type": "application/json"}, 200, "json"},
+		{"POST", "/users", map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, 200, "form"},
+		{"POST", "/users", map[string]string{"Content-Type": "text/plain"}, 415, "\"/users\" unsupported media type\n"},
+		{"GET", "/users", map[string]string{"API-Version": "2"}, 200, "v2"},
+		{"GET", "/users", nil, 200, "v1"},
+		{"GET", "/users?format=csv", map[string]string{"Accept": "text/csv"}, 200, "csv"},
+		{"GET", "/reports", map[string]string{"Accept": "text/html"}, 406, "\"/reports\" not acceptable\n"},
+		{"PUT", "/reports", nil, 405, "\"PUT\" not allowed in \"/reports\"\n"},
+	}
+	for _, v := range items {
+		req := httptest.NewRequest(v.method, v.path, nil)
+		for k, h := range v.header {
+			req.Header.Set(k, h)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != v.code || w.Body.String() != v.body {
+			t.Fatalf("%s %s %v should respond %d %q, got %d %q", v.method, v.path, v.header, v.code, v.body, w.Code, w.Body.String())
+		}
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Fatal("should panic for a duplicate handler without matchers")
+		}
+	}()
+	mux.Get("/users", handler("v1"))
+}
+
+func TestHandleWithNode(t *testing.T) {
+	mux := New()
+	v1 := func(w http.ResponseWriter, r *http.Request) {}
+	v2 := func(w http.ResponseWriter, r *http.Request) {}
+	mux.Get("/users", v1)
+	node := mux.HandleWith("GET", "/users", v2, Header("API-Version", "2"), Accept("application/json"))
+	mux.HandleWith("POST", "/users", v2, ContentType("application/json"))
+
+	// the handlers with matchers do not replace the handler of Handle
+	if _, ok := node.GetHandler("GET").(http.HandlerFunc); !ok {
+		t.Fatalf("GET handler should be an http.HandlerFunc, got %T", node.GetHandler("GET"))
+	}
+	if node.GetHandler("POST") != nil {
+		t.Fatalf("POST should have no handler without matchers, got %T", node.GetHandler("POST"))
+	}
+	if !node.Handles("POST") || node.Handles("PUT") {
+		t.Fatal("should handle POST with matchers only, and not PUT")
+	}
+	if allow := strings.Join(node.GetAllow(), ","); allow != "GET,POST" {
+		t.Fatalf("should allow GET,POST, got %s", allow)
+	}
+	if routes := mux.Routes(); len(routes) != 2 {
+		t.Fatalf("should list GET and POST /users, got %v", routes)
+	}
+
+	// the same matchers in any order are the same route
+	_, err := mux.tryHandle("GET", "/users", v1, []Matcher{Accept("application/json"), Header("API-Version", "2")})
+	if e, ok := err.(*ErrDuplicateRoute); !ok || e.Method != "GET" || e.Pattern != "/users" {
+		t.Fatalf("should return *ErrDuplicateRoute, got %v", err)
+	}
+	if _, err := mux.tryHandle("GET", "/users", v1, []Matcher{Header("API-Version", "3")}); err != nil {
+		t.Fatalf("other matchers should be registered, got %v", err)
+	}
+	if _, err := mux.tryHandle("POST", "/users", v1, []Matcher{MatcherFunc(func(*http.Request) (bool, int) { return true, 0 })}); err != nil {
+		t.Fatalf("a MatcherFunc should be registered, got %v", err)
+	}
+}
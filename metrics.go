@@ -0,0 +1,359 @@
+package mux
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the default latency histogram buckets in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics records request counts by status code, latency histograms and
+// in-flight requests per route, keyed by the registered method and pattern,
+// with method "*" for the requests handled by Any, as well as not found,
+// method not allowed, redirect and match error counts of a Mux.
+// Metrics is an http.Handler which writes the metrics in the Prometheus
+// text exposition format.
+//
+//  metrics := mux.NewMetrics()
+//  mx.Instrument(metrics)
+//  mx.Handler("GET", "/metrics", metrics)
+type Metrics struct {
+	mu               sync.Mutex
+	buckets          []float64
+	routes           map[metricsKey]*routeMetrics
+	notFound         uint64
+	methodNotAllowed uint64
+	redirects        uint64
+	matchErrors      uint64
+}
+
+type metricsKey struct {
+	method, pattern string
+}
+
+type routeMetrics struct {
+	inFlight int64
+	codes    map[int]uint64
+	buckets  []uint64
+	count    uint64
+	sum      float64
+}
+
+// NewMetrics returns a Metrics with the latency histogram buckets in seconds,
+// DefaultBuckets is used if no bucket is given.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets: buckets,
+		routes:  make(map[metricsKey]*routeMetrics),
+	}
+}
+
+// Instrument records the metrics of the requests served by the Mux.
+func (m *Mux) Instrument(metrics *Metrics) {
+	m.metrics = metrics
+}
+
+func (m *Metrics) route(method, pattern string) *routeMetrics {
+	key := metricsKey{method: method, pattern: pattern}
+	r, ok := m.routes[key]
+	if !ok {
+		r = &routeMetrics{
+			codes:   make(map[int]uint64),
+			buckets: make([]uint64, len(m.buckets)),
+		}
+		m.routes[key] = r
+	}
+	return r
+}
+
+// serve runs the handler of the route registered with the method and
+// pattern and records its metrics.
+func (m *Metrics) serve(w http.ResponseWriter, req *http.Request, method, pattern string, handler http.HandlerFunc) {
+	m.mu.Lock()
+	m.route(method, pattern).inFlight++
+	m.mu.Unlock()
+
+	sw := &statusWriter{ResponseWriter: w}
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start).Seconds()
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		r := m.route(method, pattern)
+		r.inFlight--
+		r.codes[status]++
+		r.count++
+		r.sum += elapsed
+		for i, le := range m.buckets {
+			if elapsed <= le {
+				r.buckets[i]++
+			}
+		}
+	}()
+	handler(sw.wrap(), req)
+}
+
+func (m *Metrics) observeNotFound() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.notFound++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) observeMethodNotAllowed() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.methodNotAllowed++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) observeMatchError() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.matchErrors++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) observeRedirect() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.redirects++
+	m.mu.Unlock()
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make(metricsKeys, 0, len(m.routes))
+	for k := range m.routes {
+		keys = append(keys, k)
+	}
+	sort.Sort(keys)
+
+	var b bytes.Buffer
+	writeHeader(&b, "mux_requests_total", "counter", "Total number of requests handled by a route.")
+	for _, k := range keys {
+		r := m.routes[k]
+		codes := make([]int, 0, len(r.codes))
+		for code := range r.codes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(&b, "mux_requests_total{%s,code=\"%d\"} %d\n", k.labels(), code, r.codes[code])
+		}
+	}
+	writeHeader(&b, "mux_request_duration_seconds", "histogram", "Latency of the requests handled by a route.")
+	for _, k := range keys {
+		r := m.routes[k]
+		for i, le := range m.buckets {
+			fmt.Fprintf(&b, "mux_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", k.labels(), formatFloat(le), r.buckets[i])
+		}
+		fmt.Fprintf(&b, "mux_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", k.labels(), r.count)
+		fmt.Fprintf(&b, "mux_request_duration_seconds_sum{%s} %s\n", k.labels(), formatFloat(r.sum))
+		fmt.Fprintf(&b, "mux_request_duration_seconds_count{%s} %d\n", k.labels(), r.count)
+	}
+	writeHeader(&b, "mux_requests_in_flight", "gauge", "Number of requests being handled by a route.")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "mux_requests_in_flight{%s} %d\n", k.labels(), m.routes[k].inFlight)
+	}
+	writeHeader(&b, "mux_not_found_total", "counter", "Total number of requests not matching any route.")
+	fmt.Fprintf(&b, "mux_not_found_total %d\n", m.notFound)
+	writeHeader(&b, "mux_method_not_allowed_total", "counter", "Total number of requests with a method not allowed by the route.")
+	fmt.Fprintf(&b, "mux_method_not_allowed_total %d\n", m.methodNotAllowed)
+	writeHeader(&b, "mux_redirects_total", "counter", "Total number of trailing slash redirects.")
+	fmt.Fprintf(&b, "mux_redirects_total %d\n", m.redirects)
+	writeHeader(&b, "mux_match_errors_total", "counter", "Total number of requests whose path failed to match.")
+	fmt.Fprintf(&b, "mux_match_errors_total %d\n", m.matchErrors)
+
+	return b.WriteTo(w)
+}
+
+// metricsKeys sorts routes by pattern then method.
+type metricsKeys []metricsKey
+
+func (k metricsKeys) Len() int      { return len(k) }
+func (k metricsKeys) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k metricsKeys) Less(i, j int) bool {
+	if k[i].pattern != k[j].pattern {
+		return k[i].pattern < k[j].pattern
+	}
+	return k[i].method < k[j].method
+}
+
+func (k metricsKey) labels() string {
+	return `method="` + escapeLabel(k.method) + `",route="` + escapeLabel(k.pattern) + `"`
+}
+
+func writeHeader(b *bytes.Buffer, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelReplacer.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// statusWriter records the status code written by a handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// wrap returns w implementing the optional interfaces implemented by the
+// underlying ResponseWriter among http.Flusher, http.Hijacker, io.ReaderFrom
+// and http.Pusher, and only those.
+func (w *statusWriter) wrap() http.ResponseWriter {
+	if rw := w.wrapPusher(); rw != nil {
+		return rw
+	}
+	f, h, r := statusFlusher{w}, statusHijacker{w}, statusReaderFrom{w}
+	switch w.interfaces() {
+	case flusherInterface:
+		return struct {
+			*statusWriter
+			http.Flusher
+		}{w, f}
+	case hijackerInterface:
+		return struct {
+			*statusWriter
+			http.Hijacker
+		}{w, h}
+	case readerFromInterface:
+		return struct {
+			*statusWriter
+			io.ReaderFrom
+		}{w, r}
+	case flusherInterface | hijackerInterface:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case flusherInterface | readerFromInterface:
+		return struct {
+			*statusWriter
+			http.Flusher
+			io.ReaderFrom
+		}{w, f, r}
+	case hijackerInterface | readerFromInterface:
+		return struct {
+			*statusWriter
+			http.Hijacker
+			io.ReaderFrom
+		}{w, h, r}
+	case flusherInterface | hijackerInterface | readerFromInterface:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{w, f, h, r}
+	}
+	return w
+}
+
+const (
+	flusherInterface = 1 << iota
+	hijackerInterface
+	readerFromInterface
+)
+
+// interfaces returns the optional interfaces of the underlying ResponseWriter
+// but http.Pusher, see wrap.
+func (w *statusWriter) interfaces() int {
+	i := 0
+	if _, ok := w.ResponseWriter.(http.Flusher); ok {
+		i |= flusherInterface
+	}
+	if _, ok := w.ResponseWriter.(http.Hijacker); ok {
+		i |= hijackerInterface
+	}
+	if _, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		i |= readerFromInterface
+	}
+	return i
+}
+
+type statusFlusher struct{ *statusWriter }
+
+func (w statusFlusher) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type statusHijacker struct{ *statusWriter }
+
+func (w statusHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type statusReaderFrom struct{ *statusWriter }
+
+func (w statusReaderFrom) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
@@ -0,0 +1,72 @@
+//go:build go1.8
+// +build go1.8
+
+package mux
+
+import (
+	"io"
+	"net/http"
+)
+
+// wrapPusher returns w implementing http.Pusher and the other optional
+// interfaces of the underlying ResponseWriter, or nil if it is not a Pusher.
+func (w *statusWriter) wrapPusher() http.ResponseWriter {
+	p, ok := w.ResponseWriter.(http.Pusher)
+	if !ok {
+		return nil
+	}
+	f, h, r := statusFlusher{w}, statusHijacker{w}, statusReaderFrom{w}
+	switch w.interfaces() {
+	case flusherInterface:
+		return struct {
+			*statusWriter
+			http.Pusher
+			http.Flusher
+		}{w, p, f}
+	case hijackerInterface:
+		return struct {
+			*statusWriter
+			http.Pusher
+			http.Hijacker
+		}{w, p, h}
+	case readerFromInterface:
+		return struct {
+			*statusWriter
+			http.Pusher
+			io.ReaderFrom
+		}{w, p, r}
+	case flusherInterface | hijackerInterface:
+		return struct {
+			*statusWriter
+			http.Pusher
+			http.Flusher
+			http.Hijacker
+		}{w, p, f, h}
+	case flusherInterface | readerFromInterface:
+		return struct {
+			*statusWriter
+			http.Pusher
+			http.Flusher
+			io.ReaderFrom
+		}{w, p, f, r}
+	case hijackerInterface | readerFromInterface:
+		return struct {
+			*statusWriter
+			http.Pusher
+			http.Hijacker
+			io.ReaderFrom
+		}{w, p, h, r}
+	case flusherInterface | hijackerInterface | readerFromInterface:
+		return struct {
+			*statusWriter
+			http.Pusher
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{w, p, f, h, r}
+	}
+	return struct {
+		*statusWriter
+		http.Pusher
+	}{w, p}
+}
@@ -0,0 +1,11 @@
+//go:build !go1.8
+// +build !go1.8
+
+package mux
+
+import "net/http"
+
+// wrapPusher returns nil, http.Pusher does not exist before Go 1.8.
+func (w *statusWriter) wrapPusher() http.ResponseWriter {
+	return nil
+}
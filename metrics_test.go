@@ -0,0 +1,149 @@
+package mux
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(0.5, 0.1)
+	mux := New()
+	mux.Instrument(metrics)
+	mux.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		if Param(r, ":id") == "0" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	mux.Get(`/say/:word("hi")`, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hi"))
+	})
+	mux.Get("/dir/", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Get("/broken/:id(?:x)", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handler("GET", "/metrics", metrics)
+
+	for _, v := range []struct{ method, path string }{
+		{"GET", "/users/1"},
+		{"GET", "/users/2"},
+		{"GET", "/users/0"},
+		{"GET", `/say/"hi"`},
+		{"POST", "/users/1"},
+		{"GET", "/missing"},
+		{"GET", "/dir"},
+		{"GET", "/broken/x"},
+	} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(v.method, v.path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Fatalf("wrong content type %q", ct)
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		`mux_requests_total{method="GET",route="/users/:id",code="200"} 2`,
+		`mux_requests_total{method="GET",route="/users/:id",code="404"} 1`,
+		`mux_requests_total{method="GET",route="/say/:word(\"hi\")",code="200"} 1`,
+		`mux_request_duration_seconds_bucket{method="GET",route="/users/:id",le="0.1"} 3`,
+		`mux_request_duration_seconds_bucket{method="GET",route="/users/:id",le="0.5"} 3`,
+		`mux_request_duration_seconds_bucket{method="GET",route="/users/:id",le="+Inf"} 3`,
+		`mux_request_duration_seconds_count{method="GET",route="/users/:id"} 3`,
+		`mux_requests_in_flight{method="GET",route="/users/:id"} 0`,
+		`mux_requests_in_flight{method="GET",route="/metrics"} 1`,
+		"# TYPE mux_request_duration_seconds histogram",
+		"mux_not_found_total 1",
+		"mux_method_not_allowed_total 1",
+		"mux_redirects_total 1",
+		"mux_match_errors_total 1",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("metrics should contain %q, got\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsAny(t *testing.T) {
+	metrics := NewMetrics()
+	mux := New()
+	mux.Instrument(metrics)
+	mux.Any("/x", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Get("/x", func(w http.ResponseWriter, r *http.Request) {})
+	for _, method := range []string{"RND0", "RND1", "RND2", "DELETE", "GET"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/x", nil))
+	}
+	w := httptest.NewRecorder()
+	metrics.WriteTo(w)
+	body := w.Body.String()
+	for _, line := range []string{
+		`mux_requests_total{method="*",route="/x",code="200"} 4`,
+		`mux_requests_total{method="GET",route="/x",code="200"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("metrics should contain %q, got\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "RND") {
+		t.Fatalf("metrics should not be keyed by the request method, got\n%s", body)
+	}
+}
+
+// hijackRecorder is a ResponseRecorder implementing http.Hijacker and io.ReaderFrom too.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (w hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func (w hijackRecorder) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestMetricsWriterInterfaces(t *testing.T) {
+	metrics := NewMetrics()
+	mux := New()
+	mux.Instrument(metrics)
+	var interfaces string
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		interfaces = ""
+		if _, ok := w.(http.Flusher); ok {
+			interfaces += "flusher "
+		}
+		if _, ok := w.(http.Hijacker); ok {
+			interfaces += "hijacker "
+		}
+		if rf, ok := w.(io.ReaderFrom); ok {
+			interfaces += "readerfrom "
+			rf.ReadFrom(strings.NewReader("body"))
+		}
+		if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); ok && u.Unwrap() != nil {
+			interfaces += "unwrap"
+		}
+	})
+
+	items := []struct {
+		w      http.ResponseWriter
+		expect string
+	}{
+		{httptest.NewRecorder(), "flusher unwrap"},
+		{struct{ http.ResponseWriter }{httptest.NewRecorder()}, "unwrap"},
+		{hijackRecorder{httptest.NewRecorder()}, "flusher hijacker readerfrom unwrap"},
+	}
+	for _, v := range items {
+		mux.ServeHTTP(v.w, httptest.NewRequest("GET", "/", nil))
+		if interfaces != v.expect {
+			t.Fatalf("%T: should implement %q, got %q", v.w, v.expect, interfaces)
+		}
+	}
+	w := httptest.NewRecorder()
+	metrics.WriteTo(w)
+	if line := `mux_requests_total{method="GET",route="/",code="200"} 3`; !strings.Contains(w.Body.String(), line) {
+		t.Fatalf("metrics should contain %q, got\n%s", line, w.Body.String())
+	}
+}
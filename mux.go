@@ -10,8 +10,14 @@ import (
 // Mux is a tire base HTTP request router which can be used to
 // dispatch requests to different handler functions.
 type Mux struct {
-	trie           *Trie
-	defaultHandler http.HandlerFunc
+	trie              *Trie
+	defaultHandler    http.HandlerFunc
+	metrics           *Metrics
+	tracer            Tracer
+	overrideMethods   map[string]bool
+	overrideForm      bool
+	versionExtractors []VersionExtractor
+	coverage          *Coverage
 }
 
 // New returns a Mux instance.
@@ -20,38 +26,91 @@ func New(opts ...Options) *Mux {
 }
 
 // Get registers a new GET route for a path with matching handler in the Mux.
-func (m *Mux) Get(pattern string, handler http.HandlerFunc) {
-	m.Handle(http.MethodGet, pattern, handler)
+func (m *Mux) Get(pattern string, handler http.HandlerFunc) *Node {
+	return m.Handle(http.MethodGet, pattern, handler)
 }
 
 // Head registers a new HEAD route for a path with matching handler in the Mux.
-func (m *Mux) Head(pattern string, handler http.HandlerFunc) {
-	m.Handle(http.MethodHead, pattern, handler)
+func (m *Mux) Head(pattern string, handler http.HandlerFunc) *Node {
+	return m.Handle(http.MethodHead, pattern, handler)
 }
 
 // Post registers a new POST route for a path with matching handler in the Mux.
-func (m *Mux) Post(pattern string, handler http.HandlerFunc) {
-	m.Handle(http.MethodPost, pattern, handler)
+func (m *Mux) Post(pattern string, handler http.HandlerFunc) *Node {
+	return m.Handle(http.MethodPost, pattern, handler)
 }
 
 // Put registers a new PUT route for a path with matching handler in the Mux.
-func (m *Mux) Put(pattern string, handler http.HandlerFunc) {
-	m.Handle(http.MethodPut, pattern, handler)
+func (m *Mux) Put(pattern string, handler http.HandlerFunc) *Node {
+	return m.Handle(http.MethodPut, pattern, handler)
 }
 
 // Patch registers a new PATCH route for a path with matching handler in the Mux.
-func (m *Mux) Patch(pattern string, handler http.HandlerFunc) {
-	m.Handle(http.MethodPatch, pattern, handler)
+func (m *Mux) Patch(pattern string, handler http.HandlerFunc) *Node {
+	return m.Handle(http.MethodPatch, pattern, handler)
 }
 
 // Delete registers a new DELETE route for a path with matching handler in the Mux.
-func (m *Mux) Delete(pattern string, handler http.HandlerFunc) {
-	m.Handle(http.MethodDelete, pattern, handler)
+func (m *Mux) Delete(pattern string, handler http.HandlerFunc) *Node {
+	return m.Handle(http.MethodDelete, pattern, handler)
 }
 
 // Options registers a new OPTIONS route for a path with matching handler in the Mux.
-func (m *Mux) Options(pattern string, handler http.HandlerFunc) {
-	m.Handle(http.MethodOptions, pattern, handler)
+func (m *Mux) Options(pattern string, handler http.HandlerFunc) *Node {
+	return m.Handle(http.MethodOptions, pattern, handler)
+}
+
+// Any registers a new route for all methods for a path with matching handler
+// in the Mux. Handlers registered for specific methods on the same pattern
+// take precedence.
+func (m *Mux) Any(pattern string, handler http.HandlerFunc) *Node {
+	return m.Handle(MethodAny, pattern, handler)
+}
+
+// Match registers a new route for several methods for a path with matching
+// handler in the Mux. It panics with ErrInvalidMethod if no method is given
+// or a method is invalid, or with an *ErrDuplicateRoute if a method is
+// already handled on the pattern, and then registers none of the methods.
+//
+//  mx.Match([]string{"PROPFIND", "MKCOL"}, "/dav/*path", davHandleFunc)
+func (m *Mux) Match(methods []string, pattern string, handler http.HandlerFunc) *Node {
+	node, err := m.tryMatch(methods, pattern, handler)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+func (m *Mux) tryMatch(methods []string, pattern string, handler http.HandlerFunc) (*Node, error) {
+	if len(methods) == 0 {
+		return nil, ErrInvalidMethod
+	}
+	seen := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		if !isMethod(method) {
+			return nil, ErrInvalidMethod
+		}
+		method = strings.ToUpper(method)
+		if seen[method] {
+			return nil, &ErrDuplicateRoute{Pattern: pattern, Method: method}
+		}
+		seen[method] = true
+	}
+	node, err := m.trie.TryParse(pattern)
+	if err != nil {
+		return nil, err
+	}
+	for method := range seen {
+		if _, ok := node.handlers[method]; ok {
+			return nil, &ErrDuplicateRoute{Pattern: node.pattern, Method: method}
+		}
+	}
+	for _, method := range methods {
+		if err := node.TryHandle(strings.ToUpper(method), handler); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
 }
 
 // DefaultHandler registers a new handler in the Mux
@@ -60,31 +119,103 @@ func (m *Mux) DefaultHandler(handler http.HandlerFunc) {
 	m.defaultHandler = handler
 }
 
+// Trie returns the routing trie of the Mux, e.g. to match paths in tests.
+func (m *Mux) Trie() *Trie {
+	return m.trie
+}
+
 // Handle registers a new handler with method and path in the Mux.
 // For GET, POST, PUT, PATCH and DELETE requests the respective shortcut
-// functions can be used.
-func (m *Mux) Handle(method, pattern string, handler http.HandlerFunc) {
-	if method == "" {
-		panic(fmt.Errorf("invalid method"))
+// functions can be used, any other method such as PROPFIND can be used here.
+// It panics if the route can not be registered, use TryHandle to get an error instead.
+// It returns the endpoint node of the pattern, which can be used to name the
+// route or to set its priority.
+//
+//  mx.Handle("GET", "/users/:id", showUser).Name("users.show").Priority(10)
+func (m *Mux) Handle(method, pattern string, handler http.HandlerFunc) *Node {
+	node, err := m.TryHandle(method, pattern, handler)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// TryHandle is like Handle but returns an error instead of panicking,
+// which is one of ErrInvalidMethod, *ErrInvalidPattern or *ErrDuplicateRoute.
+// It returns the endpoint node of the pattern on success.
+//
+//  node, err := mx.TryHandle("GET", "/users/:id", showUser)
+//  if err == nil {
+//  	_, err = node.TryName("users.show")
+//  }
+func (m *Mux) TryHandle(method, pattern string, handler http.HandlerFunc) (*Node, error) {
+	return m.tryHandle(method, pattern, handler, nil)
+}
+
+// HandleWith registers a new handler with method, path and matchers in the Mux.
+// Several handlers can be registered for the same method and path with
+// different matchers, the first one whose matchers all match the request is used.
+// A handler registered without matchers is used when no matchers match,
+// otherwise the request is rejected with the status of the matchers,
+// see Matcher.
+//
+//  mx.HandleWith("POST", "/users", createUserJSON, mux.ContentType("application/json"))
+//  mx.HandleWith("POST", "/users", createUserForm, mux.ContentType("application/x-www-form-urlencoded"))
+func (m *Mux) HandleWith(method, pattern string, handler http.HandlerFunc, matchers ...Matcher) *Node {
+	node, err := m.tryHandle(method, pattern, handler, matchers)
+	if err != nil {
+		panic(err)
 	}
-	m.trie.Parse(pattern).Handle(strings.ToUpper(method), handler)
+	return node
+}
+
+func (m *Mux) tryHandle(method, pattern string, handler http.HandlerFunc, matchers []Matcher) (*Node, error) {
+	if !isMethod(method) {
+		return nil, ErrInvalidMethod
+	}
+	node, err := m.trie.TryParse(pattern)
+	if err != nil {
+		return nil, err
+	}
+	method = strings.ToUpper(method)
+	if len(matchers) > 0 {
+		err = node.handleWith(method, handler, matchers)
+	} else {
+		err = node.TryHandle(method, handler)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
 }
 
 // Handler is an adapter which allows the usage of an http.Handler as a
 // request handle.
-func (m *Mux) Handler(method, path string, handler http.Handler) {
-	m.Handle(method, path, func(w http.ResponseWriter, req *http.Request) {
+func (m *Mux) Handler(method, path string, handler http.Handler) *Node {
+	return m.Handle(method, path, func(w http.ResponseWriter, req *http.Request) {
 		handler.ServeHTTP(w, req)
 	})
 }
 
 // ServeHTTP implemented http.Handler interface
 func (m *Mux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	var handler http.HandlerFunc
+	var (
+		handler http.HandlerFunc
+		route   *Route
+		status  int
+	)
+	req = m.overrideMethod(req)
 	path := req.URL.Path
 	method := req.Method
+	if m.tracer != nil {
+		req = req.WithContext(m.tracer.MatchStart(req))
+	}
 	match, err := m.trie.Match(path)
+	if m.tracer != nil {
+		m.tracer.MatchEnd(req, match, err)
+	}
 	if err != nil {
+		m.metrics.observeMatchError()
 		http.Error(w, fmt.Sprintf(`"Access %s: %s"`, path, err), http.StatusNotImplemented)
 		return
 	}
@@ -98,35 +229,118 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			if method != "GET" {
 				code = http.StatusTemporaryRedirect
 			}
+			m.metrics.observeRedirect()
+			if m.tracer != nil {
+				m.tracer.Redirect(req, req.URL.String(), code)
+			}
 			http.Redirect(w, req, req.URL.String(), code)
 			return
 		}
+		m.metrics.observeNotFound()
+		if m.tracer != nil {
+			m.tracer.NotFound(req)
+		}
 		if m.defaultHandler == nil {
 			http.Error(w, fmt.Sprintf(`"%s" not implemented`, path), http.StatusNotFound)
 			return
 		}
 		handler = m.defaultHandler
 	} else {
-		var ok bool
-		if handler, ok = match.Node.GetHandler(method).(http.HandlerFunc); !ok {
+		handler, status = match.Node.handlerFunc(method, req)
+		if handler == nil && status != 0 {
+			// rejected by the matchers of every handler
+			m.metrics.observeNotFound()
+			if m.tracer != nil {
+				m.tracer.NotFound(req)
+			}
+			if m.defaultHandler == nil {
+				http.Error(w, fmt.Sprintf(`"%s" %s`, path, strings.ToLower(http.StatusText(status))), status)
+				return
+			}
+			handler = m.defaultHandler
+		} else if handler == nil {
 			// OPTIONS preflight
 			if method == http.MethodOptions {
-				w.Header().Set("Access-Control-Allow-Methods", strings.Join(match.Node.GetAllow(), ", "))
+				allow := allowMethods(match.Node)
+				if m.tracer != nil {
+					m.tracer.Preflight(req, allow)
+				}
+				w.Header().Set("Access-Control-Allow-Methods", allow)
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
 
+			m.metrics.observeMethodNotAllowed()
+			if m.tracer != nil {
+				m.tracer.MethodNotAllowed(req, match.Node.GetAllow())
+			}
 			if m.defaultHandler == nil {
-				w.Header().Set("Access-Control-Allow-Methods", strings.Join(match.Node.GetAllow(), ", "))
+				w.Header().Set("Access-Control-Allow-Methods", allowMethods(match.Node))
+				w.Header().Set("Allow", strings.Join(match.Node.GetAllow(), ", "))
 				http.Error(w, fmt.Sprintf(`"%s" not allowed in "%s"`, method, path), 405)
 				return
 			}
 			handler = m.defaultHandler
+		} else {
+			route = &Route{
+				Pattern:  match.Node.pattern,
+				Name:     match.Node.routeName,
+				Method:   method,
+				Params:   match.Params,
+				Metadata: match.Node.routeMetadata(match.Node.handlerMethod(method)),
+			}
+			if route.Params == nil {
+				route.Params = map[string]string{}
+			}
+			if m.coverage != nil {
+				m.coverage.record(match.Node, method)
+			}
 		}
 	}
-	if match.Params != nil {
-		ctx := context.WithValue(req.Context(), routeParamsID, match.Params)
+	if match.Params != nil || route != nil {
+		ctx := req.Context()
+		if match.Params != nil {
+			ctx = context.WithValue(ctx, routeParamsID, match.Params)
+		}
+		if route != nil {
+			ctx = context.WithValue(ctx, routeID, route)
+		}
 		req = req.WithContext(ctx)
+		setPathValues(req, match.Params)
+	}
+	if route != nil && m.tracer != nil {
+		req = req.WithContext(m.tracer.HandlerStart(req, route))
+		defer m.tracer.HandlerEnd(req, route)
+	}
+	if route != nil && m.metrics != nil {
+		m.metrics.serve(w, req, match.Node.handlerMethod(method), route.Pattern, handler)
+		return
 	}
 	handler(w, req)
 }
+
+// allowMethods returns the Access-Control-Allow-Methods of the node, with
+// the wildcard "*" when MethodAny is handled.
+func allowMethods(n *Node) string {
+	allow := n.GetAllow()
+	if n.Handles(MethodAny) {
+		allow = append(allow[:len(allow):len(allow)], "*")
+	}
+	return strings.Join(allow, ", ")
+}
+
+// isMethod reports whether the method is a valid HTTP token.
+func isMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, c := range method {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			continue
+		}
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", c) {
+			return false
+		}
+	}
+	return true
+}
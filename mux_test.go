@@ -28,6 +28,19 @@ func TestMux(t *testing.T) {
 		mux := New()
 		mux.Handler("", "/:type", http.NotFoundHandler())
 	})
+	t.Run("Mux.TryHandle", func(t *testing.T) {
+		assert := assert.New(t)
+
+		mux := New()
+		_, err := mux.TryHandle("", "/:type", http.NotFound)
+		assert.Equal(ErrInvalidMethod, err)
+		_, err = mux.TryHandle("GET", "/:type([a-z]", http.NotFound)
+		assert.NotNil(err)
+		_, err = mux.TryHandle("GET", "/:type", http.NotFound)
+		assert.Nil(err)
+		_, err = mux.TryHandle("get", "/:type", http.NotFound)
+		assert.Equal(&ErrDuplicateRoute{Pattern: "/:type", Method: "GET"}, err)
+	})
 	t.Run("Mux.Handler", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -132,6 +145,80 @@ func TestMux(t *testing.T) {
 		res.Body.Close()
 	})
 
+	t.Run("router with Any and custom methods", func(t *testing.T) {
+		assert := assert.New(t)
+
+		handler := func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("any " + req.Method))
+		}
+		mux := New()
+		mux.Any("/dav/*path", handler)
+		mux.Get("/dav/*path", func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("get"))
+		})
+		mux.Match([]string{"PROPFIND", "mkcol"}, "/files/:name", handler)
+
+		_, err := mux.TryHandle("BAD METHOD", "/files", handler)
+		assert.Equal(ErrInvalidMethod, err)
+		assert.Panics(func() {
+			mux.Any("/dav/*path", handler)
+		})
+
+		for _, v := range []struct {
+			method, path, body string
+			code               int
+		}{
+			{"GET", "/dav/a/b", "get", 200},
+			{"DELETE", "/dav/a/b", "any DELETE", 200},
+			{"PROPFIND", "/dav/a/b", "any PROPFIND", 200},
+			{"PROPFIND", "/files/a", "any PROPFIND", 200},
+			{"MKCOL", "/files/a", "any MKCOL", 200},
+		} {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(v.method, v.path, nil))
+			assert.Equal(v.code, w.Code)
+			assert.Equal(v.body, w.Body.String())
+		}
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("QUERY", "/files/a", nil))
+		assert.Equal(405, w.Code)
+		assert.Equal("PROPFIND, MKCOL", w.Header().Get("Allow"))
+
+		// GetHandler does not fall back to the handler of Any
+		node := mux.Trie().Parse("/dav/*path")
+		assert.Nil(node.GetHandler("DELETE"))
+		assert.NotNil(node.GetHandler(MethodAny))
+
+		// Match registers all the methods or none
+		assert.Panics(func() {
+			mux.Match(nil, "/empty", handler)
+		})
+		_, err = mux.tryMatch([]string{"LOCK", "BAD METHOD"}, "/lock", handler)
+		assert.Equal(ErrInvalidMethod, err)
+		_, err = mux.tryMatch([]string{"LOCK", "mkcol"}, "/files/:name", handler)
+		_, ok := err.(*ErrDuplicateRoute)
+		assert.True(ok)
+		methods := []string{"lock", "unlock"}
+		_, err = mux.tryMatch(methods, "/files/:name", handler)
+		assert.Nil(err)
+		assert.Equal([]string{"lock", "unlock"}, methods)
+		assert.Equal([]string{"PROPFIND", "MKCOL", "LOCK", "UNLOCK"}, mux.Trie().Parse("/files/:name").GetAllow())
+		for _, pattern := range []string{"/empty", "/lock"} {
+			assert.Equal(0, len(mux.Trie().Parse(pattern).GetAllow()))
+		}
+
+		// OPTIONS is handled by Any, the preflight lists it as "*"
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/dav/a", nil))
+		assert.Equal("any OPTIONS", w.Body.String())
+		mux.Trie().Parse("/raw").Handle(MethodAny, "not a HandlerFunc")
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/raw", nil))
+		assert.Equal(204, w.Code)
+		assert.Equal("*", w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
 	t.Run("automatic handle `OPTIONS` method", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -299,6 +386,37 @@ func TestMux(t *testing.T) {
 		res.Body.Close()
 	})
 
+	t.Run("router with RouteFromRequest", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var route *Route
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			route = RouteFromRequest(r)
+		}
+		mux := New()
+		mux.Get("/api/users/:id", handler).Name("users.show")
+		mux.Post("/api/users", handler)
+		mux.Any("/api/any", handler)
+		mux.DefaultHandler(handler)
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users/42", nil))
+		assert.Equal(&Route{
+			Pattern: "/api/users/:id",
+			Name:    "users.show",
+			Method:  "GET",
+			Params:  map[string]string{":id": "42"},
+		}, route)
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/users", nil))
+		assert.Equal(&Route{Pattern: "/api/users", Method: "POST", Params: map[string]string{}}, route)
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/api/any", nil))
+		assert.Equal(&Route{Pattern: "/api/any", Method: "DELETE", Params: map[string]string{}}, route)
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users", nil))
+		assert.Nil(route)
+	})
+
 	t.Run("router with DefaultHandler", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -0,0 +1,171 @@
+// Package muxtest provides assertions on the routes of a mux.Mux for tests.
+//
+//  func TestRoutes(t *testing.T) {
+//  	mx := NewRouter()
+//  	muxtest.AssertRoute(t, mx, "GET", "/users/42", "users.show", map[string]string{":id": "42"})
+//  	muxtest.AssertNotFound(t, mx, "GET", "/users/42/unknown")
+//  	muxtest.AssertRequestNotFound(t, mx, httptest.NewRequest("GET", "/users", nil))
+//  	muxtest.AssertRedirect(t, mx, "/users/", "/users", http.StatusMovedPermanently)
+//  	muxtest.AssertMethodNotAllowed(t, mx, "PATCH", "/users/42", "GET", "PUT", "DELETE")
+//  }
+//
+// AssertRoute and AssertNotFound match the path with Trie.Match and never run
+// handlers: a handler registered with Mux.HandleWith counts for AssertRoute
+// and AssertNotFound whatever its matchers check. AssertRedirect, AssertMethodNotAllowed and
+// AssertRequestNotFound serve a request with Mux.ServeHTTP and httptest.
+package muxtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/beego/mux"
+)
+
+// TB is the part of testing.TB used by the assertions. The assertions are
+// marked as helpers when t has the Helper method of Go 1.9.
+type TB interface {
+	Errorf(format string, args ...interface{})
+}
+
+// helper is implemented by testing.T and testing.B since Go 1.9.
+type helper interface {
+	Helper()
+}
+
+// AssertRoute asserts that the path is matched by a route with a handler
+// for the method, see Node.Handles, with the name unless it is empty and with exactly the
+// params unless they are nil. It reports whether the assertion holds.
+func AssertRoute(t TB, m *mux.Mux, method, path, name string, params map[string]string) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	matched, err := m.Trie().Match(path)
+	switch {
+	case err != nil:
+		t.Errorf("%s %s: should match a route, got error: %s", method, path, err)
+		return false
+	case matched.Node == nil && matched.Path != "":
+		t.Errorf("%s %s: should match a route, got redirect to %q", method, path, matched.Path)
+		return false
+	case matched.Node == nil:
+		t.Errorf("%s %s: should match a route, got not found", method, path)
+		return false
+	case !matched.Node.Handles(method):
+		t.Errorf("%s %s: should match a route, got %q without %s handler, allowing %s",
+			method, path, matched.Node.GetPattern(), method, strings.Join(matched.Node.GetAllow(), ", "))
+		return false
+	}
+	ok := true
+	if name != "" && matched.Node.GetRouteName() != name {
+		t.Errorf("%s %s: should match route %q, got %q (%q)", method, path, name, matched.Node.GetRouteName(), matched.Node.GetPattern())
+		ok = false
+	}
+	if params != nil {
+		got := matched.Params
+		if got == nil {
+			got = map[string]string{}
+		}
+		if !reflect.DeepEqual(got, params) {
+			t.Errorf("%s %s: should match params %v, got %v", method, path, params, got)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertNotFound asserts that no route with a handler for the method, see
+// Node.Handles, matches the path, and that it is not redirected with or
+// without trailing slash. A route of the path without handler for the
+// method, which responds 405, counts as not found. A path which cannot be
+// matched, e.g. without leading slash, fails the assertion. It reports
+// whether the assertion holds.
+func AssertNotFound(t TB, m *mux.Mux, method, path string) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	matched, err := m.Trie().Match(path)
+	switch {
+	case err != nil:
+		t.Errorf("%s %s: should not be found, got error: %s", method, path, err)
+		return false
+	case matched.Node != nil && matched.Node.Handles(method):
+		t.Errorf("%s %s: should not be found, got route %q", method, path, matched.Node.GetPattern())
+		return false
+	case matched.Path != "":
+		t.Errorf("%s %s: should not be found, got redirect to %q", method, path, matched.Path)
+		return false
+	}
+	return true
+}
+
+// AssertRequestNotFound asserts that serving the request responds 404, so that
+// the request can carry the headers, query or host checked by the matchers of
+// Mux.HandleWith. It reports whether the assertion holds.
+func AssertRequestNotFound(t TB, m *mux.Mux, req *http.Request) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("%s %s: should not be found, got %d", req.Method, req.URL.Path, w.Code)
+		return false
+	}
+	return true
+}
+
+// AssertRedirect asserts that a GET request of the path is redirected to the
+// target with the status code. It reports whether the assertion holds.
+func AssertRedirect(t TB, m *mux.Mux, path, target string, code int) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	w := serve(m, http.MethodGet, path)
+	location := w.Header().Get("Location")
+	if w.Code != code || location != target {
+		t.Errorf("GET %s: should redirect to %q with %d, got %d %q", path, target, code, w.Code, location)
+		return false
+	}
+	return true
+}
+
+// AssertMethodNotAllowed asserts that a request of the path with the method
+// responds 405 with the allowed methods in the Allow header, in any order,
+// unless none is given. It reports whether the assertion holds.
+func AssertMethodNotAllowed(t TB, m *mux.Mux, method, path string, allow ...string) bool {
+	if h, ok := t.(helper); ok {
+		h.Helper()
+	}
+	w := serve(m, method, path)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("%s %s: should not be allowed, got %d", method, path, w.Code)
+		return false
+	}
+	if len(allow) == 0 {
+		return true
+	}
+	var got []string
+	for _, v := range strings.Split(w.Header().Get("Allow"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			got = append(got, v)
+		}
+	}
+	expected := append([]string(nil), allow...)
+	sort.Strings(got)
+	sort.Strings(expected)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("%s %s: should allow %s, got %s", method, path, strings.Join(expected, ", "), strings.Join(got, ", "))
+		return false
+	}
+	return true
+}
+
+func serve(m *mux.Mux, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
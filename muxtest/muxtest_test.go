@@ -0,0 +1,115 @@
+package muxtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beego/mux"
+)
+
+// fakeTB records the errors of an assertion. It has no Helper method, as
+// testing.T before Go 1.9.
+type fakeTB struct {
+	errors []string
+}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func newMux() *mux.Mux {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	mx := mux.New()
+	mx.Get("/users", h)
+	mx.Get("/users/:id", h).Name("users.show")
+	mx.Put("/users/:id", h)
+	mx.Get("/docs/", h)
+	mx.HandleWith("GET", "/admin", h, mux.Header("X-Admin", "1"))
+	return mx
+}
+
+func TestAssertions(t *testing.T) {
+	mx := newMux()
+	items := []struct {
+		assert func(TB) bool
+		err    string
+	}{
+		{func(t TB) bool {
+			return AssertRoute(t, mx, "GET", "/users/42", "users.show", map[string]string{":id": "42"})
+		}, ""},
+		{func(t TB) bool { return AssertRoute(t, mx, "PUT", "/users/42", "", nil) }, ""},
+		{func(t TB) bool { return AssertRoute(t, mx, "GET", "/users", "", map[string]string{}) }, ""},
+		{func(t TB) bool { return AssertRoute(t, mx, "GET", "/admin", "", nil) }, ""},
+		{func(t TB) bool { return AssertRoute(t, mx, "POST", "/admin", "", nil) },
+			`POST /admin: should match a route, got "/admin" without POST handler, allowing GET`},
+		{func(t TB) bool {
+			return AssertRoute(t, mx, "GET", "/users/42", "users.get", map[string]string{":id": "42"})
+		}, `GET /users/42: should match route "users.get", got "users.show" ("/users/:id")`},
+		{func(t TB) bool {
+			return AssertRoute(t, mx, "GET", "/users/42", "", map[string]string{":id": "43"})
+		}, `GET /users/42: should match params map[:id:43], got map[:id:42]`},
+		{func(t TB) bool { return AssertRoute(t, mx, "DELETE", "/users/42", "", nil) },
+			`DELETE /users/42: should match a route, got "/users/:id" without DELETE handler, allowing GET, PUT`},
+		{func(t TB) bool { return AssertRoute(t, mx, "GET", "/posts", "", nil) },
+			`GET /posts: should match a route, got not found`},
+		{func(t TB) bool { return AssertRoute(t, mx, "GET", "/docs", "", nil) },
+			`GET /docs: should match a route, got redirect to "/docs/"`},
+
+		{func(t TB) bool { return AssertNotFound(t, mx, "GET", "/posts") }, ""},
+		{func(t TB) bool { return AssertNotFound(t, mx, "DELETE", "/users/42") }, ""},
+		{func(t TB) bool { return AssertNotFound(t, mx, "POST", "/admin") }, ""},
+		{func(t TB) bool { return AssertNotFound(t, mx, "GET", "/admin") },
+			`GET /admin: should not be found, got route "/admin"`},
+		{func(t TB) bool { return AssertNotFound(t, mx, "GET", "/users/42") },
+			`GET /users/42: should not be found, got route "/users/:id"`},
+		{func(t TB) bool { return AssertNotFound(t, mx, "GET", "/docs") },
+			`GET /docs: should not be found, got redirect to "/docs/"`},
+		{func(t TB) bool { return AssertNotFound(t, mx, "GET", "posts") },
+			`GET posts: should not be found, got error: path is not start with "/": "posts"`},
+
+		{func(t TB) bool { return AssertRequestNotFound(t, mx, httptest.NewRequest("GET", "/posts", nil)) }, ""},
+		{func(t TB) bool { return AssertRequestNotFound(t, mx, httptest.NewRequest("GET", "/admin", nil)) }, ""},
+		{func(t TB) bool {
+			req := httptest.NewRequest("GET", "/admin", nil)
+			req.Header.Set("X-Admin", "1")
+			return AssertRequestNotFound(t, mx, req)
+		}, `GET /admin: should not be found, got 200`},
+		{func(t TB) bool { return AssertRequestNotFound(t, mx, httptest.NewRequest("DELETE", "/users/42", nil)) },
+			`DELETE /users/42: should not be found, got 405`},
+
+		{func(t TB) bool { return AssertRedirect(t, mx, "/docs", "/docs/", http.StatusMovedPermanently) }, ""},
+		{func(t TB) bool { return AssertRedirect(t, mx, "/docs", "/docs/", http.StatusFound) },
+			`GET /docs: should redirect to "/docs/" with 302, got 301 "/docs/"`},
+		{func(t TB) bool { return AssertRedirect(t, mx, "/users", "/users/", http.StatusMovedPermanently) },
+			`GET /users: should redirect to "/users/" with 301, got 200 ""`},
+
+		{func(t TB) bool { return AssertMethodNotAllowed(t, mx, "DELETE", "/users/42", "PUT", "GET") }, ""},
+		{func(t TB) bool { return AssertMethodNotAllowed(t, mx, "DELETE", "/users/42") }, ""},
+		{func(t TB) bool { return AssertMethodNotAllowed(t, mx, "DELETE", "/users/42", "GET") },
+			`DELETE /users/42: should allow GET, got GET, PUT`},
+		{func(t TB) bool { return AssertMethodNotAllowed(t, mx, "GET", "/posts") },
+			`GET /posts: should not be allowed, got 404`},
+	}
+	for i, v := range items {
+		tb := &fakeTB{}
+		ok := v.assert(tb)
+		if ok != (v.err == "") {
+			t.Fatalf("%d: should return %t", i, v.err == "")
+		}
+		switch {
+		case v.err == "" && len(tb.errors) > 0:
+			t.Fatalf("%d: should not fail, got %q", i, tb.errors)
+		case v.err != "" && (len(tb.errors) != 1 || tb.errors[0] != v.err):
+			t.Fatalf("%d: should fail with %q, got %q", i, v.err, tb.errors)
+		}
+	}
+}
+
+func TestTestingT(t *testing.T) {
+	mx := newMux()
+	AssertRoute(t, mx, "GET", "/users/42", "users.show", map[string]string{":id": "42"})
+	AssertNotFound(t, mx, "GET", "/posts")
+	AssertRequestNotFound(t, mx, httptest.NewRequest("GET", "/admin", nil))
+}
@@ -0,0 +1,71 @@
+package mux
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MethodOverrideHeader is the header consulted by the method override.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// MethodOverrideField is the form field consulted by MethodOverrideForm.
+const MethodOverrideField = "_method"
+
+// MethodOverride lets POST requests be handled as another method, for clients
+// behind proxies which can only send GET and POST. The method is read from
+// the X-HTTP-Method-Override header. The request is copied, the handler of
+// the override method receives a request with that method.
+// Only the given methods can be used, PUT, PATCH and DELETE if none is given.
+//
+//  mx.MethodOverride()
+//  mx.Delete("/users/:id", deleteUser)
+//  // POST /users/1 with X-HTTP-Method-Override: DELETE -> deleteUser
+func (m *Mux) MethodOverride(methods ...string) {
+	if len(methods) == 0 {
+		methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	m.overrideMethods = make(map[string]bool, len(methods))
+	for _, method := range methods {
+		m.overrideMethods[strings.ToUpper(method)] = true
+	}
+	m.overrideForm = false
+}
+
+// MethodOverrideForm is MethodOverride for HTML forms: without the header,
+// the method is read from the _method field of an
+// application/x-www-form-urlencoded body. The body of such POST requests is
+// parsed before routing, their handlers find the fields in Request.PostForm
+// and not in Request.Body.
+//
+//  mx.MethodOverrideForm()
+//  mx.Delete("/users/:id", deleteUser)
+//  // <form method="POST" action="/users/1"><input type="hidden" name="_method" value="DELETE"></form>
+func (m *Mux) MethodOverrideForm(methods ...string) {
+	m.MethodOverride(methods...)
+	m.overrideForm = true
+}
+
+// overrideMethod returns a copy of a POST request with the allowed override
+// method, or the request itself. The form field is only read from
+// urlencoded bodies so that other bodies are left to the handler.
+func (m *Mux) overrideMethod(req *http.Request) *http.Request {
+	if m.overrideMethods == nil || req.Method != http.MethodPost {
+		return req
+	}
+	method := req.Header.Get(MethodOverrideHeader)
+	if method == "" && m.overrideForm && isFormURLEncoded(req) {
+		method = req.PostFormValue(MethodOverrideField)
+	}
+	if method = strings.ToUpper(method); !m.overrideMethods[method] {
+		return req
+	}
+	r2 := *req
+	r2.Method = method
+	return &r2
+}
+
+func isFormURLEncoded(req *http.Request) bool {
+	ct, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return err == nil && ct == "application/x-www-form-urlencoded"
+}
@@ -0,0 +1,137 @@
+package mux
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	mux := New()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	}
+	mux.Post("/users/:id", handler)
+	mux.Delete("/users/:id", handler)
+	mux.Put("/users/:id", handler)
+	mux.Get("/users/:id", handler)
+
+	newForm := func(method string) *http.Request {
+		req := httptest.NewRequest("POST", "/users/1", strings.NewReader("_method="+method))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+	newHeader := func(method string) *http.Request {
+		req := httptest.NewRequest("POST", "/users/1", nil)
+		req.Header.Set("X-HTTP-Method-Override", method)
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, newForm("DELETE"))
+	if w.Body.String() != "POST" {
+		t.Fatalf("method override should be disabled by default, got %s", w.Body.String())
+	}
+
+	mux.MethodOverride()
+	items := []struct {
+		req    *http.Request
+		method string
+	}{
+		{newHeader("DELETE"), "DELETE"},
+		{newHeader("put"), "PUT"},
+		{newHeader("CONNECT"), "POST"},
+		{newHeader(""), "POST"},
+		// the form field is only read by MethodOverrideForm
+		{newForm("DELETE"), "POST"},
+	}
+	for _, v := range items {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, v.req)
+		if w.Body.String() != v.method {
+			t.Fatalf("should handle as %s, got %s", v.method, w.Body.String())
+		}
+	}
+
+	mux.MethodOverrideForm()
+	items = []struct {
+		req    *http.Request
+		method string
+	}{
+		{newForm("DELETE"), "DELETE"},
+		{newForm("put"), "PUT"},
+		{newForm("GET"), "POST"},
+		{newHeader("DELETE"), "DELETE"},
+		{newHeader("CONNECT"), "POST"},
+	}
+	for _, v := range items {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, v.req)
+		if w.Body.String() != v.method {
+			t.Fatalf("should handle as %s, got %s", v.method, w.Body.String())
+		}
+	}
+
+	// the header is used before the form field
+	req := newForm("PUT")
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Body.String() != "DELETE" || req.PostForm != nil {
+		t.Fatalf("should handle as DELETE without reading the body, got %s %v", w.Body.String(), req.PostForm)
+	}
+
+	req = httptest.NewRequest("GET", "/users/1?_method=DELETE", nil)
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Body.String() != "GET" {
+		t.Fatalf("only POST should be overridden, got %s", w.Body.String())
+	}
+}
+
+func TestMethodOverrideRequest(t *testing.T) {
+	mux := New()
+	mux.MethodOverrideForm()
+	var got *http.Request
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte(r.Method + " " + string(body)))
+	}
+	mux.Post("/users/:id", handler)
+	mux.Delete("/users/:id", handler)
+
+	// the request of the caller is not changed
+	req := httptest.NewRequest("POST", "/users/1", nil)
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Body.String() != "DELETE " || req.Method != "POST" || got == req {
+		t.Fatalf("should handle a copy as DELETE, got %s and %s", w.Body.String(), req.Method)
+	}
+
+	// only urlencoded bodies are read
+	items := []struct {
+		contentType string
+		body        string
+		expect      string
+	}{
+		{"application/x-www-form-urlencoded; charset=utf-8", "_method=DELETE", "DELETE "},
+		{"application/json", "_method=DELETE", "POST _method=DELETE"},
+		{"text/plain", "_method=DELETE", "POST _method=DELETE"},
+		{"multipart/form-data; boundary=x", "--x\r\nContent-Disposition: form-data; name=\"_method\"\r\n\r\nDELETE\r\n--x--\r\n",
+			"POST --x\r\nContent-Disposition: form-data; name=\"_method\"\r\n\r\nDELETE\r\n--x--\r\n"},
+	}
+	for _, v := range items {
+		req := httptest.NewRequest("POST", "/users/1", strings.NewReader(v.body))
+		req.Header.Set("Content-Type", v.contentType)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Body.String() != v.expect {
+			t.Fatalf("%s: should handle as %q, got %q", v.contentType, v.expect, w.Body.String())
+		}
+	}
+}
@@ -9,8 +9,42 @@ type key int
 const (
 	// RouteParamsID represent the key to store matched route params
 	routeParamsID key = iota
+	// routeID represent the key to store the matched route
+	routeID
 )
 
+// Route describes the route which handles a request.
+type Route struct {
+	// Pattern is the pattern of the route as registered, e.g. "/users/:id".
+	Pattern string
+
+	// Name is the name of the route, if any.
+	Name string
+
+	// Method is the method of the request. The handler is registered with
+	// it, or with MethodAny when the route has no handler of its own for it.
+	Method string
+
+	// Params are the matched params.
+	Params map[string]string
+
+	// Metadata is a copy of the metadata of the route set with Node.Meta or
+	// by LoadRoutes for the method, if any.
+	Metadata map[string]string
+}
+
+// RouteFromRequest returns the route which handles the request,
+// or nil if the request is handled by the default handler.
+//
+//  route := mux.RouteFromRequest(r)
+//  log.Printf("%s %s", route.Method, route.Pattern) // GET /users/:id
+func RouteFromRequest(r *http.Request) *Route {
+	if v, ok := r.Context().Value(routeID).(*Route); ok {
+		return v
+	}
+	return nil
+}
+
 // Params return the router params
 func Params(r *http.Request) map[string]string {
 	v := r.Context().Value(routeParamsID)
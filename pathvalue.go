@@ -0,0 +1,17 @@
+//go:build go1.22
+// +build go1.22
+
+package mux
+
+import (
+	"net/http"
+	"strings"
+)
+
+// setPathValues makes the params available with http.Request.PathValue,
+// the names are stored without the leading colon.
+func setPathValues(req *http.Request, params map[string]string) {
+	for k, v := range params {
+		req.SetPathValue(strings.TrimPrefix(k, ":"), v)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !go1.22
+// +build !go1.22
+
+package mux
+
+import (
+	"net/http"
+)
+
+// setPathValues does nothing, http.Request.PathValue requires Go 1.22.
+func setPathValues(req *http.Request, params map[string]string) {}
@@ -0,0 +1,23 @@
+//go:build go1.22
+// +build go1.22
+
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathValue(t *testing.T) {
+	mux := New()
+	mux.Get("/api/:type/*path", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("type") + " " + r.PathValue("path") + " " + Param(r, ":type")))
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/users/1/posts", nil))
+	if body := w.Body.String(); body != "users 1/posts users" {
+		t.Fatalf("should set path values, got %q", body)
+	}
+}
@@ -0,0 +1,620 @@
+package mux
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// refMatcher is a slow reference implementation of the matching rules of
+// the README. It shares no code with the trie: the patterns are kept as a
+// list of routes, their segments are translated to regexps by refCompile,
+// and the routes are tried in the order the README gives:
+//
+//  - the path is matched segment by segment, a route takes a segment when
+//    its pattern segment matches it
+//  - static segments are tried first, then params, then regexp params and
+//    wildcards; params, and regexp params and wildcards, are tried by
+//    descending priority of the routes sharing the segment, then by adding
+//    order
+//  - the first route taking a segment wins it for the routes sharing its
+//    pattern segment, the other routes are dropped: there is no
+//    backtracking
+//  - a param does not take an empty segment, nor a segment the routes
+//    sharing it cannot go on from: the path continues and they end, or the
+//    path ends and none of them ends or has an optional param next
+//  - an optional param takes an empty last segment with an empty value,
+//    and a path ending before it matches the route of the first optional
+//    param by priority, then adding order
+//  - a wildcard takes the segments up to the first one taken by a route
+//    sharing it, *.* takes the rest of the path split at its first dot
+//  - the last segment is tried again without a .json, .xml or .html
+//    extension, which is the :ext param
+//  - a path with a trailing slash matching no route redirects to the path
+//    without it if a route has it, a path matching no route redirects to
+//    the path with a trailing slash if a route has it
+type refMatcher struct {
+	routes []*refRoute
+}
+
+type refRoute struct {
+	pattern  string
+	segments []refSegment
+	priority int
+}
+
+type refResult struct {
+	pattern, redirect string
+	params            map[string]string
+}
+
+// refSegment is a segment of a pattern.
+type refSegment struct {
+	text     string
+	class    int // refStatic, refParam or refVary
+	value    string
+	re       *regexp.Regexp
+	names    []string
+	optional bool
+	wildcard bool
+	ext      bool
+}
+
+// The classes of segments, in the order they are tried.
+const (
+	refStatic = iota
+	refParam
+	refVary
+)
+
+func (m *refMatcher) add(pattern string, priority int) {
+	for _, r := range m.routes {
+		if r.pattern == pattern {
+			r.priority = priority
+			return
+		}
+	}
+	r := &refRoute{pattern: pattern, priority: priority}
+	for _, segment := range strings.Split(pattern[1:], "/") {
+		r.segments = append(r.segments, refCompile(segment))
+	}
+	m.routes = append(m.routes, r)
+}
+
+// refCompile translates a pattern segment.
+func refCompile(segment string) refSegment {
+	s := refSegment{text: segment, class: refVary}
+	switch {
+	case segment == "*.*":
+		s.wildcard, s.ext = true, true
+	case segment == "*":
+		s.wildcard, s.names = true, []string{":splat"}
+	case segment != "" && segment[0] == '*' && refWord(segment[1:]):
+		s.wildcard, s.names = true, []string{":" + segment[1:]}
+	case strings.Contains(segment, "::") || !strings.Contains(segment, ":"):
+		s.class, s.value = refStatic, strings.Replace(segment, "::", ":", -1)
+	case strings.HasPrefix(segment, "?:") && refWord(segment[2:]):
+		s.class, s.names, s.optional = refParam, []string{segment[1:]}, true
+	case segment[0] == ':' && refWord(segment[1:]):
+		s.class, s.names = refParam, []string{segment}
+	default:
+		var expr bytes.Buffer
+		for i := 0; i < len(segment); {
+			if strings.HasPrefix(segment[i:], "?:") {
+				s.optional = true
+				i++
+				continue
+			}
+			if segment[i] != ':' {
+				// the text around the params is a part of the regexp
+				expr.WriteByte(segment[i])
+				i++
+				continue
+			}
+			j := i + 1
+			for j < len(segment) && refWord(segment[j:j+1]) {
+				j++
+			}
+			s.names = append(s.names, segment[i:j])
+			switch rest := segment[j:]; {
+			case strings.HasPrefix(rest, ":int"):
+				expr.WriteString("([0-9]+)")
+				j += len(":int")
+			case strings.HasPrefix(rest, ":string"):
+				expr.WriteString(`([\w]+)`)
+				j += len(":string")
+			case strings.HasPrefix(rest, "("):
+				end := refGroupEnd(rest)
+				expr.WriteString(rest[:end])
+				j += end
+			default:
+				expr.WriteString("(.+)")
+			}
+			i = j
+		}
+		s.re = regexp.MustCompile(expr.String())
+	}
+	return s
+}
+
+func refWord(s string) bool {
+	for _, c := range s {
+		if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// refGroupEnd returns the length of the group s starts with.
+func refGroupEnd(s string) int {
+	depth, class := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case class:
+			class = s[i] != ']'
+		case s[i] == '[':
+			class = true
+			if strings.HasPrefix(s[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(s[i+1:], "]") {
+				i++
+			}
+		case s[i] == '(':
+			depth++
+		case s[i] == ')':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	panic("unclosed group in " + s)
+}
+
+func (m *refMatcher) match(path string) refResult {
+	res := refResult{params: map[string]string{}}
+	routes, depth := m.routes, 0
+	segs := strings.Split(path[1:], "/")
+	for i := 0; i < len(segs); {
+		seg, rest := segs[i], segs[i+1:]
+		r := refTake(routes, depth, seg, rest)
+		if r == nil && len(rest) == 0 {
+			if seg == "" && refEnding(routes, depth) != nil {
+				res.redirect = path[:len(path)-1]
+			}
+			for _, ext := range []string{".json", ".xml", ".html"} {
+				if r = refTake(routes, depth, strings.TrimSuffix(seg, ext), nil); r != nil && strings.HasSuffix(seg, ext) {
+					seg = strings.TrimSuffix(seg, ext)
+					res.params[":ext"] = ext[1:]
+					break
+				}
+				r = nil
+			}
+		}
+		if r == nil {
+			return res
+		}
+		s := r.segments[depth]
+		routes, depth = refSharing(routes, depth, s.text), depth+1
+		switch {
+		case s.ext:
+			v := strings.Join(segs[i:], "/")
+			dot := strings.IndexByte(v, '.')
+			res.params[":path"], res.params[":ext"] = v[:dot], v[dot+1:]
+			i = len(segs)
+		case s.wildcard:
+			j := i + 1
+			for j < len(segs) && refTake(routes, depth, segs[j], segs[j+1:]) == nil {
+				j++
+			}
+			res.params[s.names[0]] = strings.Join(segs[i:j], "/")
+			i = j
+		case s.class == refParam:
+			res.params[s.names[0]] = seg
+			i++
+		case s.class == refVary:
+			values := s.re.FindStringSubmatch(seg)
+			for k, name := range s.names {
+				res.params[name] = ""
+				if values != nil {
+					res.params[name] = values[k+1]
+				}
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	if r := refEnding(routes, depth); r != nil {
+		res.pattern = r.pattern
+		return res
+	}
+	for _, r := range routes {
+		if len(r.segments) > depth && r.segments[depth].text == "" {
+			res.redirect = path + "/"
+			return res
+		}
+	}
+	for _, r := range refOrder(routes, depth, false) {
+		if r.segments[depth].optional {
+			if e := refEnding(refSharing(routes, depth, r.segments[depth].text), depth+1); e != nil {
+				res.pattern = e.pattern
+			}
+			return res
+		}
+	}
+	return res
+}
+
+// refTake returns the first route taking the segment after depth segments,
+// rest is the rest of the path.
+func refTake(routes []*refRoute, depth int, seg string, rest []string) *refRoute {
+	for _, r := range refOrder(routes, depth, true) {
+		s := r.segments[depth]
+		sharing := refSharing(routes, depth, s.text)
+		switch {
+		case s.class == refStatic:
+			if s.value == seg {
+				return r
+			}
+		case s.optional && seg == "" && len(rest) == 0:
+			if refEnding(sharing, depth+1) != nil {
+				return r
+			}
+		case s.class == refParam:
+			if seg == "" {
+				continue
+			}
+			if len(rest) > 0 && !refLonger(sharing, depth+1) {
+				continue
+			}
+			if len(rest) == 0 && refEnding(sharing, depth+1) == nil && !refOptionalNext(sharing, depth+1) {
+				continue
+			}
+			return r
+		case s.ext:
+			v := strings.Join(append([]string{seg}, rest...), "/")
+			if dot := strings.IndexByte(v, '.'); dot > 0 && dot < len(v)-1 {
+				return r
+			}
+		case s.wildcard:
+			if seg != "" {
+				return r
+			}
+		case s.re.MatchString(seg):
+			return r
+		}
+	}
+	return nil
+}
+
+// refOrder returns the routes longer than depth segments in the order
+// their segment after depth segments is tried, by class first if classes.
+func refOrder(routes []*refRoute, depth int, classes bool) []*refRoute {
+	type key struct{ class, rank, first int }
+	keys := map[string]*key{}
+	var order []*refRoute
+	for i, r := range routes {
+		if len(r.segments) <= depth {
+			continue
+		}
+		s := r.segments[depth]
+		k, ok := keys[s.text]
+		if !ok {
+			k = &key{class: s.class, rank: r.priority, first: i}
+			keys[s.text] = k
+		}
+		if r.priority > k.rank {
+			k.rank = r.priority
+		}
+		order = append(order, r)
+	}
+	less := func(a, b *key) bool {
+		if classes && a.class != b.class {
+			return a.class < b.class
+		}
+		if a.rank != b.rank {
+			return a.rank > b.rank
+		}
+		return a.first < b.first
+	}
+	// insertion sort keeps the adding order of the routes of a segment
+	for i := range order {
+		for j := i; j > 0 && less(keys[order[j].segments[depth].text], keys[order[j-1].segments[depth].text]); j-- {
+			order[j-1], order[j] = order[j], order[j-1]
+		}
+	}
+	return order
+}
+
+// refSharing returns the routes having the segment after depth segments.
+func refSharing(routes []*refRoute, depth int, text string) []*refRoute {
+	var sharing []*refRoute
+	for _, r := range routes {
+		if len(r.segments) > depth && r.segments[depth].text == text {
+			sharing = append(sharing, r)
+		}
+	}
+	return sharing
+}
+
+// refEnding returns the route of depth segments.
+func refEnding(routes []*refRoute, depth int) *refRoute {
+	for _, r := range routes {
+		if len(r.segments) == depth {
+			return r
+		}
+	}
+	return nil
+}
+
+func refLonger(routes []*refRoute, depth int) bool {
+	for _, r := range routes {
+		if len(r.segments) > depth {
+			return true
+		}
+	}
+	return false
+}
+
+func refOptionalNext(routes []*refRoute, depth int) bool {
+	for _, r := range routes {
+		if len(r.segments) > depth && r.segments[depth].optional {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	refSegments = []string{
+		"a", "b", "ab", ":id", ":name", "?:opt", ":id:int", ":name:string", ":x([a-c]+)",
+		"v:n:int", "f_:id:int", "?:n:int", "?:s:string", ":y(^(?:[0-9]+)$)", "*", "*w", "*.*", "",
+	}
+	refPathSegments = []string{
+		"a", "b", "ab", "c", "1", "12", "x1", "f_3", "v2", "a.json", "1.html", "x.y", "a.b.c", "-b",
+	}
+)
+
+// refPattern returns a random pattern, optional params, *.* and empty
+// segments are only last.
+func refPattern(rnd *rand.Rand) string {
+	var segments []string
+	for n := 1 + rnd.Intn(3); len(segments) < n; {
+		s := refSegments[rnd.Intn(len(refSegments))]
+		last := len(segments) == n-1
+		if !last && (s == "" || s == "*.*" || strings.Contains(s, "?:")) {
+			continue
+		}
+		segments = append(segments, s)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func refPath(rnd *rand.Rand) string {
+	var segments []string
+	for n := 1 + rnd.Intn(4); len(segments) < n; {
+		segments = append(segments, refPathSegments[rnd.Intn(len(refPathSegments))])
+	}
+	if rnd.Intn(4) == 0 {
+		segments = append(segments, "")
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func trieResult(t *Trie, path string) (refResult, error) {
+	matched, err := t.Match(path)
+	if err != nil {
+		return refResult{}, err
+	}
+	res := refResult{redirect: matched.Path}
+	if matched.Node != nil {
+		res.pattern, res.params = matched.Node.pattern, matched.Params
+		if res.params == nil {
+			res.params = map[string]string{}
+		}
+	}
+	return res, nil
+}
+
+func TestMatchReference(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 3000; i++ {
+		tr := NewTrie()
+		ref := &refMatcher{}
+		var registered []string
+		for n := 1 + rnd.Intn(6); n > 0; n-- {
+			pattern := refPattern(rnd)
+			priority := 0
+			if rnd.Intn(4) == 0 {
+				priority = 1 + rnd.Intn(2)
+			}
+			node := tr.Parse(pattern)
+			if node.GetHandler("GET") == nil {
+				node.Handle("GET", pattern)
+			}
+			// a pattern added again changes its priority
+			node.Priority(priority)
+			ref.add(pattern, priority)
+			registered = append(registered, fmt.Sprintf("%s (%d)", pattern, priority))
+		}
+		for j := 0; j < 30; j++ {
+			path := refPath(rnd)
+			expected := ref.match(path)
+			if expected.pattern == "" {
+				// params are only defined for a matched route
+				expected.params = nil
+			}
+			got, err := trieResult(tr, path)
+			if err != nil || !reflect.DeepEqual(got, expected) {
+				t.Fatalf("%s with routes %q: should match %+v, got %+v %v", path, registered, expected, got, err)
+			}
+		}
+	}
+}
+
+// TestMatchingOrder checks the examples of the README with the trie and the
+// reference matcher.
+func TestMatchingOrder(t *testing.T) {
+	items := []struct {
+		routes  []string
+		path    string
+		pattern string
+		params  map[string]string
+	}{
+		// static pattern > parameters' pattern > regexp pattern
+		{[]string{"/abc/:id:int", "/abc/:id", "/abc/99"}, "/abc/99", "/abc/99", map[string]string{}},
+		{[]string{"/abc/:id:int", "/abc/:id", "/abc/99"}, "/abc/123", "/abc/:id", map[string]string{":id": "123"}},
+		{[]string{"/abc/:id:int", "/abc/99"}, "/abc/123", "/abc/:id:int", map[string]string{":id": "123"}},
+		// the first one in adding order
+		{[]string{"/abc/?:id:int", "/abc/?:name:string"}, "/abc", "/abc/?:id:int", map[string]string{}},
+		{[]string{"/abc/?:id:int", "/abc/?:name:string"}, "/abc/123", "/abc/?:id:int", map[string]string{":id": "123"}},
+		{[]string{"/abc/?:id:int", "/abc/?:name:string"}, "/abc/zzz", "/abc/?:name:string", map[string]string{":name": "zzz"}},
+		{[]string{"/xyz/?:name:string", "/xyz/?:id:int"}, "/xyz", "/xyz/?:name:string", map[string]string{}},
+		{[]string{"/xyz/?:name:string", "/xyz/?:id:int"}, "/xyz/123", "/xyz/?:name:string", map[string]string{":name": "123"}},
+		// then by priority, "!" is a priority of 10
+		{[]string{"/xyz/?:name:string", "/xyz/?:id:int!"}, "/xyz/123", "/xyz/?:id:int", map[string]string{":id": "123"}},
+		{[]string{"/xyz/?:name:string", "/xyz/?:id:int!"}, "/xyz/zzz", "/xyz/?:name:string", map[string]string{":name": "zzz"}},
+		// params do not match an empty segment, optional params do
+		{[]string{"/abc/:id"}, "/abc/", "", nil},
+		{[]string{"/abc/xyz/?:id"}, "/abc/xyz/", "/abc/xyz/?:id", map[string]string{":id": ""}},
+		{[]string{"/abc/xyz/?:id:int"}, "/abc/xyz/", "/abc/xyz/?:id:int", map[string]string{":id": ""}},
+		{[]string{"/abc/xyz/?:id:int"}, "/abc/xyz", "/abc/xyz/?:id:int", map[string]string{}},
+		{[]string{"/abc/?:name:string"}, "/abc/-b", "/abc/?:name:string", map[string]string{":name": "b"}},
+		{[]string{"/abc/?:name:string"}, "/abc/-", "", nil},
+		// regexp params match a part of the segment, the text around the
+		// params is a part of the regexp
+		{[]string{"/abc/:id:int"}, "/abc/a1b", "/abc/:id:int", map[string]string{":id": "1"}},
+		{[]string{"/abc/article_:id:int"}, "/abc/article_123", "/abc/article_:id:int", map[string]string{":id": "123"}},
+		{[]string{"/abc/article_:id:int"}, "/abc/x_article_123", "/abc/article_:id:int", map[string]string{":id": "123"}},
+		{[]string{"/abc/article_:id:int"}, "/abc/article_x", "", nil},
+		{[]string{"/cms_:id([0-9]+).html"}, "/cms_12xhtml", "/cms_:id([0-9]+).html", map[string]string{":id": "12"}},
+		// wildcards
+		{[]string{"/abc/*/xyz"}, "/abc/12/34/xyz", "/abc/*/xyz", map[string]string{":splat": "12/34"}},
+		{[]string{"/abc/*/xyz"}, "/abc/xyz", "", nil},
+		{[]string{"/a/*left/b/*right"}, "/a/x/y/b/z", "/a/*left/b/*right", map[string]string{":left": "x/y", ":right": "z"}},
+		{[]string{"/abc/*.*"}, "/abc/123/xyz.html", "/abc/*.*", map[string]string{":path": "123/xyz", ":ext": "html"}},
+		{[]string{"/abc/*.*"}, "/abc/xyz", "", nil},
+		{[]string{"/data/:year/*/list"}, "/data/2012/11/12/list", "/data/:year/*/list", map[string]string{":year": "2012", ":splat": "11/12"}},
+		// extensions
+		{[]string{"/abc/xyz"}, "/abc/xyz.json", "/abc/xyz", map[string]string{":ext": "json"}},
+		{[]string{"/abc/:id:int"}, "/abc/12.xml", "/abc/:id:int", map[string]string{":id": "12"}},
+		{[]string{"/abc/:id"}, "/abc/123.html", "/abc/:id", map[string]string{":id": "123.html"}},
+	}
+	for _, v := range items {
+		tr := NewTrie()
+		ref := &refMatcher{}
+		for _, pattern := range v.routes {
+			priority := 0
+			if strings.HasSuffix(pattern, "!") {
+				pattern, priority = pattern[:len(pattern)-1], 10
+			}
+			tr.Parse(pattern).Priority(priority).Handle("GET", pattern)
+			ref.add(pattern, priority)
+		}
+		expected := refResult{pattern: v.pattern, params: v.params}
+		got := ref.match(v.path)
+		if got.pattern == "" {
+			got.params = nil
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("%s with %q: reference should match %+v, got %+v", v.path, v.routes, expected, got)
+		}
+		got, err := trieResult(tr, v.path)
+		if err != nil || !reflect.DeepEqual(got, expected) {
+			t.Fatalf("%s with %q: should match %+v, got %+v %v", v.path, v.routes, expected, got, err)
+		}
+	}
+}
+
+// loadMatchCorpus returns the routes of testdata/match.yaml, and the paths
+// of testdata/match_paths.txt and of the FuzzMatch corpus. The matcher
+// generated by muxgen in cmd/muxgen/internal/corpus is checked on the same
+// routes and paths.
+func loadMatchCorpus(t testing.TB) ([]RouteConfig, []string) {
+	f, err := os.Open(filepath.Join("testdata", "match.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	configs, err := ParseRoutes(f, "match.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "match_paths.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	files, err := filepath.Glob(filepath.Join("testdata", "fuzz", "FuzzMatch", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// go test fuzz v1
+		// string("/path")
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		v := strings.TrimSuffix(strings.TrimPrefix(lines[len(lines)-1], "string("), ")")
+		path, err := strconv.Unquote(v)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		paths = append(paths, path)
+	}
+	return configs, paths
+}
+
+// TestMatchReferenceCorpus checks the trie with the reference matcher on
+// the match corpus, and random paths of the segments of its paths.
+func TestMatchReferenceCorpus(t *testing.T) {
+	configs, paths := loadMatchCorpus(t)
+	tr := NewTrie()
+	ref := &refMatcher{}
+	for _, c := range configs {
+		tr.Parse(c.Pattern).Priority(c.Priority).Handle(c.Method, c.Pattern)
+		ref.add(c.Pattern, c.Priority)
+	}
+	var segments []string
+	for _, path := range paths {
+		segments = append(segments, strings.Split(path[1:], "/")...)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		var b []string
+		for n := 1 + rnd.Intn(4); len(b) < n; {
+			b = append(b, segments[rnd.Intn(len(segments))])
+		}
+		paths = append(paths, "/"+strings.Join(b, "/"))
+	}
+	for _, path := range paths {
+		// the trie cleans the paths
+		cleaned := path
+		for strings.Contains(cleaned, "//") {
+			cleaned = strings.Replace(cleaned, "//", "/", -1)
+		}
+		expected := ref.match(cleaned)
+		if expected.pattern == "" {
+			expected.params = nil
+		}
+		got, err := trieResult(tr, path)
+		if err != nil || !reflect.DeepEqual(got, expected) {
+			t.Fatalf("%q: should match %+v, got %+v %v", path, expected, got, err)
+		}
+	}
+}
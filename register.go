@@ -0,0 +1,52 @@
+package mux
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var registered = struct {
+	sync.Mutex
+	funcs map[string]func() *Mux
+}{funcs: make(map[string]func() *Mux)}
+
+// Register makes a function building a Mux available by name to tools such
+// as cmd/muxroutes, usually from an init function of the package defining
+// the routes. It panics if the name is already registered.
+//
+//  func init() {
+//  	mux.Register("api", NewRouter)
+//  }
+func Register(name string, fn func() *Mux) {
+	registered.Lock()
+	defer registered.Unlock()
+	if _, ok := registered.funcs[name]; ok {
+		panic(fmt.Sprintf("mux: Register called twice for %q", name))
+	}
+	registered.funcs[name] = fn
+}
+
+// Registered returns a Mux built by the function registered with the name,
+// or nil if there is none.
+func Registered(name string) *Mux {
+	registered.Lock()
+	fn := registered.funcs[name]
+	registered.Unlock()
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
+
+// RegisteredNames returns the sorted names of the registered functions.
+func RegisteredNames() []string {
+	registered.Lock()
+	defer registered.Unlock()
+	names := make([]string, 0, len(registered.funcs))
+	for name := range registered.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
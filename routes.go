@@ -0,0 +1,196 @@
+package mux
+
+import (
+	"sort"
+	"strings"
+)
+
+// NodeKind describes how a node of the trie matches a path segment.
+type NodeKind string
+
+const (
+	// KindStatic matches the segment literally.
+	KindStatic NodeKind = "static"
+	// KindParam matches any segment, e.g. ":id".
+	KindParam NodeKind = "param"
+	// KindOptional matches any segment or none, e.g. "?:id".
+	KindOptional NodeKind = "optional"
+	// KindRegexp matches a segment with a regexp, e.g. ":id(\d+)" or ":id:int".
+	KindRegexp NodeKind = "regexp"
+	// KindWildcard matches the rest of the path, e.g. "*" or "*filepath".
+	KindWildcard NodeKind = "wildcard"
+)
+
+// Kind returns how the node matches its segment.
+func (n *Node) Kind() NodeKind {
+	switch {
+	case n.wildcard:
+		return KindWildcard
+	case n.optional:
+		return KindOptional
+	case n.regex != nil:
+		return KindRegexp
+	case len(n.name) > 0:
+		return KindParam
+	}
+	return KindStatic
+}
+
+// Parent returns the node of the previous segment of the pattern, nil for
+// the root node.
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Segment returns the segment of the pattern matched by the node, e.g.
+// "cms_:id([0-9]+).html".
+func (n *Node) Segment() string {
+	return n.segment
+}
+
+// Regexp returns the regexp matching the segment of a regexp node, e.g.
+// `cms_([0-9]+).html` for "cms_:id([0-9]+).html", or empty.
+func (n *Node) Regexp() string {
+	if n.wildcard || n.regex == nil {
+		return ""
+	}
+	return n.regex.String()
+}
+
+// RouteInfo describes a registered route, see Trie.Routes.
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	Name    string `json:"name,omitempty"`
+
+	// Params are the params of the pattern in order.
+	Params []ParamInfo `json:"params,omitempty"`
+
+	// Kind is the kind of the endpoint node of the pattern.
+	Kind NodeKind `json:"kind"`
+
+	Priority int               `json:"priority,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ParamInfo describes a param of a pattern.
+type ParamInfo struct {
+	// Name is the param name with the leading colon, e.g. ":id".
+	Name string `json:"name"`
+
+	// Type is "int" or "string" for typed params such as ":id:int",
+	// "regexp" for params with a regexp, "wildcard" for wildcards,
+	// otherwise "any".
+	Type string `json:"type"`
+
+	// Regexp is the regexp of the param if Type is "regexp".
+	Regexp string `json:"regexp,omitempty"`
+
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Routes returns the registered routes depth first in the order of the trie:
+// on each level sorted static segments, then params, then regexps and
+// wildcards, in matching order. Routes on the same pattern are sorted by
+// method, MethodAny last.
+func (t *Trie) Routes() []RouteInfo {
+	var routes []RouteInfo
+	t.root.walk(func(n *Node) {
+		methods := n.methods()
+		if len(methods) == 0 {
+			return
+		}
+		sort.Strings(methods)
+		if methods[0] == MethodAny {
+			methods = append(methods[1:], MethodAny)
+		}
+		pattern := n.pattern
+		if pattern == "" {
+			pattern = n.getSegments()
+		}
+		params := n.paramInfos()
+		for _, method := range methods {
+			routes = append(routes, RouteInfo{
+				Method:   method,
+				Pattern:  pattern,
+				Name:     n.routeName,
+				Params:   params,
+				Kind:     n.Kind(),
+				Priority: n.priority,
+				Metadata: n.routeMetadata(method),
+			})
+		}
+	})
+	return routes
+}
+
+// Routes returns the registered routes, see Trie.Routes.
+func (m *Mux) Routes() []RouteInfo {
+	return m.trie.Routes()
+}
+
+// paramInfos returns the params of the segments from the root to the node.
+func (n *Node) paramInfos() []ParamInfo {
+	if n.parent == nil {
+		return n.SegmentParams()
+	}
+	return append(n.parent.paramInfos(), n.SegmentParams()...)
+}
+
+// SegmentParams returns the params of the segment of the node in order.
+func (n *Node) SegmentParams() []ParamInfo {
+	var params []ParamInfo
+	switch n.Kind() {
+	case KindStatic:
+	case KindWildcard:
+		for _, name := range n.name {
+			params = append(params, ParamInfo{Name: name, Type: "wildcard"})
+		}
+	case KindParam:
+		params = append(params, ParamInfo{Name: n.name[0], Type: "any"})
+	default:
+		if n.regex == nil {
+			params = append(params, ParamInfo{Name: n.name[0], Type: "any", Optional: true})
+			break
+		}
+		for _, name := range n.name {
+			p := segmentParamInfo(n.segment, name)
+			p.Optional = n.optional
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// segmentParamInfo returns the type of a param in a regexp segment such as
+// "cms_:id([0-9]+).html" or ":id:int".
+func segmentParamInfo(segment, name string) ParamInfo {
+	p := ParamInfo{Name: name, Type: "any"}
+	i := strings.Index(segment, name)
+	for i >= 0 && i+len(name) < len(segment) && isWordByte(segment[i+len(name)]) {
+		j := strings.Index(segment[i+1:], name)
+		if j < 0 {
+			return p
+		}
+		i += j + 1
+	}
+	if i < 0 {
+		return p
+	}
+	rest := segment[i+len(name):]
+	switch {
+	case strings.HasPrefix(rest, ":int"):
+		p.Type = "int"
+	case strings.HasPrefix(rest, ":string"):
+		p.Type = "string"
+	case strings.HasPrefix(rest, "("):
+		if end := strings.IndexByte(rest, ')'); end > 0 {
+			p.Type, p.Regexp = "regexp", rest[1:end]
+		}
+	}
+	return p
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,92 @@
+package mux
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	mx := New()
+	mx.Get("/users/:id:int", h).Name("users.show").Meta("team", "accounts")
+	mx.Put("/users/:id:int", h)
+	mx.Any("/users/:id:int", h)
+	mx.Get("/users", h)
+	mx.Get("/files/*filepath", h)
+	mx.Get("/cms_:id([0-9]+)_:slug.html", h)
+	mx.Get("/posts/:year/?:month", h).Priority(3)
+
+	routes := mx.Routes()
+	expected := []RouteInfo{
+		{Method: "GET", Pattern: "/files/*filepath", Kind: KindWildcard,
+			Params: []ParamInfo{{Name: ":filepath", Type: "wildcard"}}},
+		{Method: "GET", Pattern: "/posts/:year/?:month", Kind: KindOptional, Priority: 3,
+			Params: []ParamInfo{{Name: ":year", Type: "any"}, {Name: ":month", Type: "any", Optional: true}}},
+		{Method: "GET", Pattern: "/users", Kind: KindStatic},
+		{Method: "GET", Pattern: "/users/:id:int", Name: "users.show", Kind: KindRegexp,
+			Params: []ParamInfo{{Name: ":id", Type: "int"}}, Metadata: map[string]string{"team": "accounts"}},
+		{Method: "PUT", Pattern: "/users/:id:int", Name: "users.show", Kind: KindRegexp,
+			Params: []ParamInfo{{Name: ":id", Type: "int"}}, Metadata: map[string]string{"team": "accounts"}},
+		{Method: MethodAny, Pattern: "/users/:id:int", Name: "users.show", Kind: KindRegexp,
+			Params: []ParamInfo{{Name: ":id", Type: "int"}}, Metadata: map[string]string{"team": "accounts"}},
+		{Method: "GET", Pattern: "/cms_:id([0-9]+)_:slug.html", Kind: KindRegexp,
+			Params: []ParamInfo{{Name: ":id", Type: "regexp", Regexp: "[0-9]+"}, {Name: ":slug", Type: "any"}}},
+	}
+	if len(routes) != len(expected) {
+		t.Fatalf("should return %d routes, got %d: %+v", len(expected), len(routes), routes)
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(routes[i], expected[i]) {
+			t.Fatalf("%d: should return %+v, got %+v", i, expected[i], routes[i])
+		}
+	}
+}
+
+func TestNodeSegments(t *testing.T) {
+	node := NewTrie().Parse("/articles/cms_:id([0-9]+)_:slug.html")
+	if node.Segment() != "cms_:id([0-9]+)_:slug.html" || node.Regexp() != "cms_([0-9]+)_(.+).html" {
+		t.Fatalf("wrong segment %q or regexp %q", node.Segment(), node.Regexp())
+	}
+	expected := []ParamInfo{{Name: ":id", Type: "regexp", Regexp: "[0-9]+"}, {Name: ":slug", Type: "any"}}
+	if params := node.SegmentParams(); !reflect.DeepEqual(params, expected) {
+		t.Fatalf("should return params %+v, got %+v", expected, params)
+	}
+	parent := node.Parent()
+	if parent.Segment() != "articles" || parent.Kind() != KindStatic || parent.SegmentParams() != nil {
+		t.Fatalf("wrong parent %q", parent.Segment())
+	}
+	if parent.Parent() == nil || parent.Parent().Parent() != nil {
+		t.Fatal("the parent of the first segment should be the root")
+	}
+	if node := NewTrie().Parse("/files/*filepath"); node.Regexp() != "" {
+		t.Fatalf("a wildcard should have no regexp, got %q", node.Regexp())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test.routes", func() *Mux {
+		mx := New()
+		mx.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+		return mx
+	})
+	if mx := Registered("test.routes"); mx == nil || len(mx.Routes()) != 1 {
+		t.Fatal("should build the registered Mux")
+	}
+	if Registered("test.missing") != nil {
+		t.Fatal("should return nil for a name not registered")
+	}
+	found := false
+	for _, name := range RegisteredNames() {
+		found = found || name == "test.routes"
+	}
+	if !found {
+		t.Fatal("should list the registered name")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("should panic when registering a name twice")
+		}
+	}()
+	Register("test.routes", func() *Mux { return New() })
+}
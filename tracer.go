@@ -0,0 +1,87 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer receives callbacks around routing and handler execution of a Mux,
+// e.g. to create spans named after the route pattern. Embed NopTracer to
+// implement only some of the callbacks.
+type Tracer interface {
+	// MatchStart is called before matching the request path, the returned
+	// context replaces the request context.
+	MatchStart(req *http.Request) context.Context
+
+	// MatchEnd is called with the result of Trie.Match, matched is nil when
+	// err is not nil.
+	MatchEnd(req *http.Request, matched *Matched, err error)
+
+	// HandlerStart is called before running the handler of the matched
+	// route, the returned context is passed to the handler.
+	HandlerStart(req *http.Request, route *Route) context.Context
+
+	// HandlerEnd is called when the handler of the matched route returns.
+	HandlerEnd(req *http.Request, route *Route)
+
+	// Redirect is called when the request is redirected to the path
+	// with or without trailing slash.
+	Redirect(req *http.Request, url string, code int)
+
+	// NotFound is called when no route matches the path, or when the
+	// matchers of the route reject the request, before running the default
+	// handler.
+	NotFound(req *http.Request)
+
+	// MethodNotAllowed is called when the matched route has no handler for
+	// the method, with its allowed methods, before running the default
+	// handler.
+	MethodNotAllowed(req *http.Request, allow []string)
+
+	// Preflight is called when an OPTIONS request is answered with the
+	// Access-Control-Allow-Methods of the matched route.
+	Preflight(req *http.Request, allow string)
+}
+
+// NopTracer is a Tracer which does nothing.
+type NopTracer struct{}
+
+// MatchStart returns the request context.
+func (NopTracer) MatchStart(req *http.Request) context.Context { return req.Context() }
+
+// MatchEnd does nothing.
+func (NopTracer) MatchEnd(req *http.Request, matched *Matched, err error) {}
+
+// HandlerStart returns the request context.
+func (NopTracer) HandlerStart(req *http.Request, route *Route) context.Context { return req.Context() }
+
+// HandlerEnd does nothing.
+func (NopTracer) HandlerEnd(req *http.Request, route *Route) {}
+
+// Redirect does nothing.
+func (NopTracer) Redirect(req *http.Request, url string, code int) {}
+
+// NotFound does nothing.
+func (NopTracer) NotFound(req *http.Request) {}
+
+// MethodNotAllowed does nothing.
+func (NopTracer) MethodNotAllowed(req *http.Request, allow []string) {}
+
+// Preflight does nothing.
+func (NopTracer) Preflight(req *http.Request, allow string) {}
+
+// Trace sets the Tracer of the Mux.
+//
+//  type spanTracer struct {
+//  	mux.NopTracer
+//  }
+//
+//  func (spanTracer) HandlerStart(r *http.Request, route *mux.Route) context.Context {
+//  	ctx, _ := tracer.Start(r.Context(), route.Method+" "+route.Pattern)
+//  	return ctx
+//  }
+//
+//  mx.Trace(spanTracer{})
+func (m *Mux) Trace(tracer Tracer) {
+	m.tracer = tracer
+}
@@ -0,0 +1,105 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type spanKey struct{}
+
+type recordTracer struct {
+	NopTracer
+	events []string
+}
+
+func (t *recordTracer) MatchStart(req *http.Request) context.Context {
+	t.events = append(t.events, "match start "+req.URL.Path)
+	return context.WithValue(req.Context(), spanKey{}, "span")
+}
+
+func (t *recordTracer) MatchEnd(req *http.Request, matched *Matched, err error) {
+	if err != nil {
+		t.events = append(t.events, fmt.Sprintf("match end %t %v", matched == nil, err))
+		return
+	}
+	pattern := ""
+	if matched.Node != nil {
+		pattern = matched.Node.GetPattern()
+	}
+	t.events = append(t.events, fmt.Sprintf("match end %q %v", pattern, matched.Params))
+}
+
+func (t *recordTracer) HandlerStart(req *http.Request, route *Route) context.Context {
+	t.events = append(t.events, "handler start "+route.Pattern+" "+req.Context().Value(spanKey{}).(string))
+	return req.Context()
+}
+
+func (t *recordTracer) HandlerEnd(req *http.Request, route *Route) {
+	t.events = append(t.events, "handler end "+route.Pattern)
+}
+
+func (t *recordTracer) Redirect(req *http.Request, url string, code int) {
+	t.events = append(t.events, fmt.Sprintf("redirect %s %d", url, code))
+}
+
+func (t *recordTracer) NotFound(req *http.Request) {
+	t.events = append(t.events, "not found")
+}
+
+func (t *recordTracer) MethodNotAllowed(req *http.Request, allow []string) {
+	t.events = append(t.events, fmt.Sprintf("method not allowed %v", allow))
+}
+
+func (t *recordTracer) Preflight(req *http.Request, allow string) {
+	t.events = append(t.events, "preflight "+allow)
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &recordTracer{}
+	mux := New()
+	mux.Trace(tracer)
+	mux.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		tracer.events = append(tracer.events, "handler")
+	})
+	mux.Get("/dir/", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, v := range []struct{ method, path string }{
+		{"GET", "/users/1"},
+		{"POST", "/users/1"},
+		{"GET", "/missing"},
+		{"GET", "/dir"},
+		{"OPTIONS", "/users/1"},
+		{"OPTIONS", "*"},
+	} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(v.method, v.path, nil))
+	}
+
+	expect := []string{
+		"match start /users/1",
+		`match end "/users/:id" map[:id:1]`,
+		"handler start /users/:id span",
+		"handler",
+		"handler end /users/:id",
+		"match start /users/1",
+		`match end "/users/:id" map[:id:1]`,
+		"method not allowed [GET]",
+		"match start /missing",
+		`match end "" map[]`,
+		"not found",
+		"match start /dir",
+		`match end "" map[]`,
+		"redirect /dir/ 301",
+		"match start /users/1",
+		`match end "/users/:id" map[:id:1]`,
+		"preflight GET",
+		"match start *",
+		`match end true path is not start with "/": "*"`,
+	}
+	if strings.Join(tracer.events, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("should trace\n%s\ngot\n%s", strings.Join(expect, "\n"), strings.Join(tracer.events, "\n"))
+	}
+}
@@ -11,10 +11,14 @@ import (
 // Version holds the current mux version
 const Version = "0.0.1"
 
+// MethodAny is the method name of a handler used for all methods
+// that have no handler of their own on a node.
+const MethodAny = "*"
+
 var (
 	allowSuffixExt = []string{".json", ".xml", ".html"}
 	// *.*  :path  :ext
-	extWildRegexp = regexp.MustCompile(`([^.]+).(.+)`)
+	extWildRegexp = regexp.MustCompile(`^([^.]+)\.(.+)$`)
 	// *    :splat
 	wildRegexp = regexp.MustCompile(`(.+)`)
 	// :string
@@ -23,6 +27,8 @@ var (
 	paramRegexp = regexp.MustCompile(`^:\w+$`)
 	// optional param
 	optionalParamRegexp = regexp.MustCompile(`^\?:\w+$`)
+	// named wildcard *name
+	wildParamRegexp = regexp.MustCompile(`^\*\w+$`)
 
 	defaultOptions = Options{
 		CaseSensitive:  true,
@@ -60,6 +66,21 @@ type Options struct {
 	// If not called, the router will match the unencoded path to the routes.
 	// For eg. "/path/foo%2Fbar/to" will match the path "/path/foo/bar/to"
 	UseEncodedPath bool
+
+	// Conflicts defines what to do when a new pattern is shadowed by or is
+	// ambiguous with a registered one. The default value is ConflictAllow.
+	Conflicts ConflictPolicy
+
+	// OnConflict receives the conflicts found with ConflictWarn policy.
+	// When nil, conflicts are written to the standard logger.
+	OnConflict func(*Conflict)
+
+	// BraceSyntax accepts brace placeholders as used by OpenAPI, gorilla/mux
+	// and http.ServeMux alongside the colon syntax. Patterns are stored in
+	// colon syntax and params keep the leading colon.
+	// "/users/{id}" is "/users/:id", "/users/{id:[0-9]+}" is "/users/:id(^(?:[0-9]+)$)",
+	// "/users/{id?}" is "/users/?:id" and "/files/{path...}" is "/files/*path".
+	BraceSyntax bool
 }
 
 // NewTrie returns a trie
@@ -79,6 +100,9 @@ func NewTrie(args ...Options) *Trie {
 		pathClean:      opts.PathClean,
 		strictSlash:    opts.StrictSlash,
 		useEncodedPath: opts.UseEncodedPath,
+		conflictPolicy: opts.Conflicts,
+		onConflict:     opts.OnConflict,
+		braceSyntax:    opts.BraceSyntax,
 		root: &Node{
 			parent:   nil,
 			children: make(map[string]*Node),
@@ -93,10 +117,14 @@ type Trie struct {
 	pathClean      bool
 	strictSlash    bool
 	useEncodedPath bool
+	conflictPolicy ConflictPolicy
+	onConflict     func(*Conflict)
+	braceSyntax    bool
 	root           *Node
 }
 
 // Parse will parse the pattern and returns the endpoint node for the pattern.
+// It panics if the pattern is invalid, use TryParse to get an error instead.
 //
 //  trie := New()
 //  node1 := trie.Parse("/a")
@@ -105,18 +133,57 @@ type Trie struct {
 //  // node2.parent == node1
 //  // node2 == node3
 func (t *Trie) Parse(pattern string) *Node {
-	if strings.Contains(pattern, "//") {
-		panic(fmt.Errorf(`multi-slash exist: "%s"`, pattern))
+	node, err := t.TryParse(pattern)
+	if err != nil {
+		panic(err)
 	}
-	_pattern := strings.TrimPrefix(pattern, "/")
+	return node
+}
+
+// TryParse is like Parse but returns an *ErrInvalidPattern instead of
+// panicking when the pattern is invalid, or a *Conflict when the pattern
+// conflicts with a registered one under the ConflictError policy.
+// The trie is left untouched on error.
+//
+//  node, err := trie.TryParse("/a/:id([0-9]+")
+//  // err.(*ErrInvalidPattern).Column == 7
+func (t *Trie) TryParse(pattern string) (*Node, error) {
+	colonPattern, spans, err := t.normalize(pattern)
+	if err != nil {
+		return nil, err
+	}
+	_pattern := colonPattern
 	if !t.caseSensitive {
 		_pattern = strings.ToLower(_pattern)
 	}
-	node := parsePattern(t.root, strings.Split(_pattern, "/"))
+	// validate what is parsed, lowercasing may change a regexp
+	if err := validatePattern(_pattern); err != nil {
+		if e, ok := err.(*ErrInvalidPattern); ok {
+			e.Pattern = pattern
+			e.Column = braceColumn(spans, e.Column)
+		}
+		return nil, err
+	}
+	if err := t.checkConflicts(pattern); err != nil {
+		return nil, err
+	}
+	node, err := parsePattern(t.root, strings.Split(strings.TrimPrefix(_pattern, "/"), "/"))
+	if err != nil {
+		return nil, err
+	}
 	if node.pattern == "" {
-		node.pattern = pattern
+		node.pattern, node.colonPattern = pattern, colonPattern
 	}
-	return node
+	return node, nil
+}
+
+// normalize returns the pattern in colon syntax, with the spans mapping it
+// back to the pattern in brace syntax.
+func (t *Trie) normalize(pattern string) (string, []braceSpan, error) {
+	if !t.braceSyntax {
+		return pattern, nil, nil
+	}
+	return translateBraces(pattern)
 }
 
 // Match try to match path. It will returns a Matched instance that
@@ -125,14 +192,25 @@ func (t *Trie) Parse(pattern string) *Node {
 //  matched, err := trie.Match("/a/b")
 //
 func (t *Trie) Match(path string) (*Matched, error) {
+	return t.match(path, nil)
+}
+
+// match matches the path, recording every step in x if it is not nil.
+func (t *Trie) match(path string, x *Explanation) (*Matched, error) {
 	if path == "" || path[0] != '/' {
 		return nil, fmt.Errorf(`path is not start with "/": "%s"`, path)
 	}
 	if t.pathClean {
-		path = pathClean(path)
+		if cleaned := pathClean(path); cleaned != path {
+			x.step("clean", cleaned, nil, "path cleaned")
+			path = cleaned
+		}
 	}
 	if !t.caseSensitive {
-		path = strings.ToLower(path)
+		if lower := strings.ToLower(path); lower != path {
+			x.step("lowercase", lower, nil, "path lowercased")
+			path = lower
+		}
 	}
 
 	start := 1
@@ -144,18 +222,22 @@ func (t *Trie) Match(path string) (*Matched, error) {
 			continue
 		}
 		segment := path[start:i]
-		node := matchNode(parent, segment, path[i:])
+		node := matchNode(parent, segment, path[i:], x)
 		if node == nil {
 			// TrailingSlashRedirect: /abc/efg/ -> /abc/efg
 			if parent.endpoint && i == end && segment == "" {
 				matched.Path = path[:end-1]
+				x.step("redirect", segment, parent, "no route with trailing slash, redirect to the path without it")
 			}
 			// match suffixext match
 			if i == end {
 				for _, ext := range allowSuffixExt {
 					if strings.HasSuffix(segment, ext) {
-						node = matchNode(parent, strings.TrimSuffix(segment, ext), path[i:])
+						x.step("suffix", strings.TrimSuffix(segment, ext), parent, "retry without the extension")
+						node = matchNode(parent, strings.TrimSuffix(segment, ext), path[i:], x)
 						if node != nil {
+							// the params are in the segment without the extension
+							segment = strings.TrimSuffix(segment, ext)
 							if matched.Params == nil {
 								matched.Params = make(map[string]string)
 							}
@@ -165,6 +247,9 @@ func (t *Trie) Match(path string) (*Matched, error) {
 					}
 				}
 			}
+			if matched.Path == "" {
+				x.step("not found", segment, parent, "no child matches the segment")
+			}
 			return matched, nil
 		}
 	ParentNode:
@@ -176,29 +261,25 @@ func (t *Trie) Match(path string) (*Matched, error) {
 			if parent.wildcard {
 				// match *
 				if len(parent.name) == 1 {
-					segs := strings.Split(path[start:end], "/")
-					starValue := []string{}
-					for {
-						if len(segs) > 0 {
-							starValue = append(starValue, segs[0])
-						}
-						if len(segs) == 1 {
-							break
-						} else {
-							segs = segs[1:]
+					// extend the value segment by segment until the
+					// next segment matches a child of the wildcard
+					wildStart := start
+					for i < end {
+						next := end
+						if j := strings.IndexByte(path[i+1:], '/'); j >= 0 {
+							next = i + 1 + j
 						}
-						n := matchNode(parent, segs[0], strings.Join(segs, "/"))
-						if n != nil {
-							matched.Params[parent.name[0]] = strings.Join(starValue, "/")
-							parent = n
-							i = i + 1 + len(segs[0])
-							start = start + len(strings.Join(starValue, "/"))
-							goto END
-						} else {
-							i = i + 1 + len(segs[0])
+						x.step("backtrack", path[i+1:next], parent, "try to end the wildcard before the segment")
+						if n := matchNode(parent, path[i+1:next], path[next:], x); n != nil {
+							matched.Params[parent.name[0]] = path[wildStart:i]
+							x.step("wildcard", path[wildStart:i], parent, "wildcard ends, the next segment matches a child")
+							node, segment, start, i = n, path[i+1:next], i+1, next
+							goto ParentNode
 						}
+						i = next
 					}
-					matched.Params[parent.name[0]] = strings.Join(starValue, "/")
+					matched.Params[parent.name[0]] = path[wildStart:end]
+					x.step("wildcard", path[wildStart:end], parent, "wildcard matches the rest of the path")
 				} else {
 					// match *.*
 					values := parent.regex.FindStringSubmatch(path[start:end])
@@ -212,28 +293,40 @@ func (t *Trie) Match(path string) (*Matched, error) {
 				break
 			} else if parent.regex == nil { // :name
 				matched.Params[parent.name[0]] = segment
+			} else if parent.optional && segment == "" {
+				// an optional param matched the empty last segment
+				for _, name := range parent.name {
+					matched.Params[name] = ""
+				}
 			} else {
 				values := parent.regex.FindStringSubmatch(segment)
+				if len(values) < len(parent.name)+1 {
+					return nil, fmt.Errorf("%s: Find wrong match %v, need names %v", path, values, parent.name)
+				}
 				for i, name := range parent.name {
 					matched.Params[name] = values[i+1]
 				}
 			}
 		}
 		start = i + 1
-	END:
 	}
 
 	switch {
 	case parent.endpoint:
 		matched.Node = parent
+		x.step("endpoint", "", parent, "matched the route")
 	case parent.getChild("") != nil:
 		// TrailingSlashRedirect: /abc/efg -> /abc/efg/
 		matched.Path = path + "/"
+		x.step("redirect", "", parent, "no route, redirect to the path with trailing slash")
 	case len(parent.optionChildren) > 0:
 		for _, child := range parent.optionChildren {
 			matched.Node = child
+			x.step("endpoint", "", child, "matched the route without its optional param")
 			break
 		}
+	default:
+		x.step("not found", "", parent, "the node is not an endpoint")
 	}
 
 	return matched, nil
@@ -255,16 +348,21 @@ type Matched struct {
 // Node represents a node on defined patterns that can be matched.
 type Node struct {
 	name, allow                  []string
-	pattern, segment             string
+	pattern, segment, routeName  string
+	colonPattern                 string
 	endpoint, wildcard, optional bool
+	priority, rank, order        int
 	parent                       *Node
 	segChildren                  []*Node
 	optionChildren               []*Node
 	varyChildren                 []*Node
 	children                     map[string]*Node
 	handlers                     map[string]interface{}
+	conditions                   map[string][]conditionalRoute
 	regex                        *regexp.Regexp
 	namedRoutes                  map[string]*Node
+	metadata                     map[string]string
+	methodMetadata               map[string]map[string]string
 }
 
 func (n *Node) getSegments() string {
@@ -302,16 +400,47 @@ func (n *Node) getChild(key string) *Node {
 }
 
 // Name sets the name for the route, used to build URLs.
+// It panics if the name is already used, use TryName to get an error instead.
 func (n *Node) Name(name string) *Node {
-	if n.getRootNode().namedRoutes == nil {
-		n.getRootNode().namedRoutes = map[string]*Node{name: n}
+	if _, err := n.TryName(name); err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// TryName is like Name but returns an *ErrDuplicateName instead of
+// panicking when the name is already used by a route.
+func (n *Node) TryName(name string) (*Node, error) {
+	root := n.getRootNode()
+	if root.namedRoutes == nil {
+		root.namedRoutes = map[string]*Node{name: n}
 	} else {
-		if _, ok := n.getRootNode().namedRoutes[name]; ok {
-			panic(fmt.Errorf("mux: route already has name %q, can't set", name))
+		if v, ok := root.namedRoutes[name]; ok {
+			return nil, &ErrDuplicateName{Name: name, Pattern: v.pattern}
 		}
-		n.getRootNode().namedRoutes[name] = n
+		root.namedRoutes[name] = n
 	}
-	return n
+	n.routeName = name
+	return n, nil
+}
+
+// GetRouteName returns the name set with Name, if any.
+func (n *Node) GetRouteName() string {
+	return n.routeName
+}
+
+// GetPattern returns the pattern of the route as registered.
+func (n *Node) GetPattern() string {
+	return n.pattern
+}
+
+// GetColonPattern returns the pattern of the route in colon syntax, which
+// differs from GetPattern for a pattern in brace syntax.
+//
+//  trie := NewTrie(Options{BraceSyntax: true})
+//  trie.Parse("/users/{id:[0-9]+}").GetColonPattern() // "/users/:id(^(?:[0-9]+)$)"
+func (n *Node) GetColonPattern() string {
+	return n.colonPattern
 }
 
 // GetName returns the name for the route, if any.
@@ -322,6 +451,119 @@ func (n *Node) GetName(name string) *Node {
 	return nil
 }
 
+// Priority sets the priority of the route, the default is 0.
+// Params and regexp params on the same level are tried by descending priority
+// of the routes under them, then by adding order. Static segments are always
+// tried before params, and params before regexp params.
+// The priority reorders whole param subtrees: as the trie never backtracks,
+// the routes under a param tried first shadow the routes under its siblings
+// for the paths the param matches.
+//
+//  trie.Parse("/users/:name:string").Handle("GET", byName)
+//  trie.Parse("/users/:id:int").Priority(10).Handle("GET", byID)
+//  // "/users/123" matches "/users/:id:int"
+func (n *Node) Priority(priority int) *Node {
+	n.priority = priority
+	for node := n; node.parent != nil; node = node.parent {
+		node.rank = node.priority
+		node.eachChild(func(c *Node) {
+			if c.rank > node.rank {
+				node.rank = c.rank
+			}
+		})
+		parent := node.parent
+		sortByRank(parent.segChildren)
+		sortByRank(parent.optionChildren)
+		sortByRank(parent.varyChildren)
+	}
+	return n
+}
+
+// GetPriority returns the priority of the route.
+func (n *Node) GetPriority() int {
+	return n.priority
+}
+
+// Meta sets a metadata value on the route for all its methods, which is
+// available to the handler in Route.Metadata.
+//
+//  mx.Get("/users/:id", showUser).Meta("team", "accounts")
+func (n *Node) Meta(key, value string) *Node {
+	if n.metadata == nil {
+		n.metadata = make(map[string]string)
+	}
+	n.metadata[key] = value
+	return n
+}
+
+// GetMeta returns the metadata value of the route for the key, if any.
+func (n *Node) GetMeta(key string) string {
+	return n.metadata[key]
+}
+
+// metaMethod sets a metadata value on the route of the method only.
+func (n *Node) metaMethod(method, key, value string) {
+	if n.methodMetadata == nil {
+		n.methodMetadata = make(map[string]map[string]string)
+	}
+	if n.methodMetadata[method] == nil {
+		n.methodMetadata[method] = make(map[string]string)
+	}
+	n.methodMetadata[method][key] = value
+}
+
+// routeMetadata returns a copy of the metadata of the route of the method,
+// the values of the method override the values set with Meta.
+func (n *Node) routeMetadata(method string) map[string]string {
+	mm := n.methodMetadata[method]
+	if len(n.metadata) == 0 && len(mm) == 0 {
+		return nil
+	}
+	metadata := make(map[string]string, len(n.metadata)+len(mm))
+	for k, v := range n.metadata {
+		metadata[k] = v
+	}
+	for k, v := range mm {
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// eachChild calls fn for every direct child of the node.
+func (n *Node) eachChild(fn func(*Node)) {
+	for _, c := range n.children {
+		fn(c)
+	}
+	for _, c := range n.segChildren {
+		fn(c)
+	}
+	for _, c := range n.varyChildren {
+		fn(c)
+	}
+}
+
+// sortByRank orders sibling nodes by descending rank, keeping adding order for ties.
+func sortByRank(nodes []*Node) {
+	for i := 1; i < len(nodes); i++ {
+		for j := i; j > 0 && (nodes[j-1].rank < nodes[j].rank ||
+			nodes[j-1].rank == nodes[j].rank && nodes[j-1].order > nodes[j].order); j-- {
+			nodes[j-1], nodes[j] = nodes[j], nodes[j-1]
+		}
+	}
+}
+
+// appendByRank adds the node after all siblings of the same or higher rank.
+func appendByRank(nodes []*Node, n *Node) []*Node {
+	i := len(nodes)
+	for i > 0 && nodes[i-1].rank < n.rank {
+		i--
+	}
+	nodes = append(nodes, nil)
+	copy(nodes[i+1:], nodes[i:])
+	nodes[i] = n
+	return nodes
+}
+
 // getRootNode will return the Node whose parent is nil
 func (n *Node) getRootNode() *Node {
 	if n.parent == nil {
@@ -344,7 +586,7 @@ func (n *Node) BuildURL(pairs ...string) (*url.URL, error) {
 			params[key] = v
 		}
 	}
-	path, err := buildPath(strings.Split(n.pattern, "/"), params)
+	path, err := buildPath(strings.Split(n.colonPattern, "/"), params)
 	if err != nil {
 		return nil, err
 	}
@@ -377,6 +619,12 @@ func buildPath(segments []string, params map[string]string) (string, error) {
 			} else {
 				results = append(results, p+"."+e)
 			}
+		} else if wildParamRegexp.MatchString(segment) {
+			v, ok := params[":"+segment[1:]]
+			if !ok {
+				return "", fmt.Errorf("%s need to map to :%s, but the pairs doesn't exist the key :%s", segment, segment[1:], segment[1:])
+			}
+			results = append(results, v)
 		} else if optionalParamRegexp.MatchString(segment) {
 			v, ok := params[segment[1:]]
 			if !ok {
@@ -390,19 +638,27 @@ func buildPath(segments []string, params map[string]string) (string, error) {
 			}
 			results = append(results, v)
 		} else if strings.ContainsAny(segment, ":") {
-			names, regex, optional := regexpSegment(segment)
+			names, regex, optional, err := parseRegexpSegment(segment)
+			if err != nil {
+				return "", err
+			}
 			rules := regex.String()
+			// groups are searched after the values, which can have parens
+			pos := 0
 			for _, name := range names {
-				if v, ok := params[name]; !ok {
+				// an optional param can be empty, as when it is matched by
+				// the empty last segment
+				if v, ok := params[name]; !ok || optional && v == "" {
 					if optional {
 						continue
 					} else {
 						return "", fmt.Errorf("the pairs doesn't exist the key %s for %s", name, segment)
 					}
 				} else {
-					start := strings.IndexRune(rules, '(')
-					end := strings.IndexByte(rules, ')')
+					start := pos + strings.IndexRune(rules[pos:], '(')
+					end := groupEnd(rules, start)
 					rules = rules[:start] + v + rules[end+1:]
+					pos = start + len(v)
 				}
 			}
 			if rules != regex.String() {
@@ -415,7 +671,42 @@ func buildPath(segments []string, params map[string]string) (string, error) {
 	return strings.Join(results, "/"), nil
 }
 
+// groupEnd returns the index of the parenthesis closing the group of the
+// regexp opened at start, which may have groups inside, such as
+// (^(?:a|b)$). Escaped parentheses and those of character classes are
+// skipped.
+func groupEnd(expr string, start int) int {
+	depth, class := 0, false
+	for i := start; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '\\':
+			i++
+		case class:
+			class = c != ']'
+		case c == '[':
+			// a ] right after [ or [^ is a literal
+			class = true
+			if strings.HasPrefix(expr[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(expr[i+1:], "]") {
+				i++
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // Handle is used to mount a handler with a method name to the node.
+// Any method name can be used, Mux uses the handler of MethodAny for
+// the methods without their own handler.
+// It panics if the method is already handled, use TryHandle to get an error instead.
 //
 //  t := New()
 //  node := t.Define("/a/b")
@@ -423,11 +714,26 @@ func buildPath(segments []string, params map[string]string) (string, error) {
 //  node.Handle("POST", handler1)
 //
 func (n *Node) Handle(method string, handler interface{}) {
-	if n.GetHandler(method) != nil {
-		panic(fmt.Errorf(`"%s" already defined`, n.getSegments()))
+	if err := n.TryHandle(method, handler); err != nil {
+		panic(err)
+	}
+}
+
+// TryHandle is like Handle but returns an *ErrDuplicateRoute instead of
+// panicking when a handler is already defined for the method.
+func (n *Node) TryHandle(method string, handler interface{}) error {
+	if _, ok := n.handlers[method]; ok {
+		pattern := n.pattern
+		if pattern == "" {
+			pattern = n.getSegments()
+		}
+		return &ErrDuplicateRoute{Pattern: pattern, Method: method}
 	}
 	n.handlers[method] = handler
-	n.allow = append(n.allow, method)
+	if _, ok := n.conditions[method]; !ok && method != MethodAny {
+		n.allow = append(n.allow, method)
+	}
+	return nil
 }
 
 // GetHandler ...
@@ -440,11 +746,52 @@ func (n *Node) Handle(method string, handler interface{}) {
 //  trie.Match("/api").Node.GetHandler("GET").(func()) == handler1
 //  trie.Match("/api").Node.GetHandler("PUT").(func()) == handler2
 //
+//  // the handler of MethodAny is only returned for MethodAny
+//  trie.Match("/api").Node.GetHandler(mux.MethodAny)
+//
 func (n *Node) GetHandler(method string) interface{} {
 	return n.handlers[method]
 }
 
-// GetAllow returns allow methods defined on the node
+// handlerMethod returns the method whose handlers handle the method on the
+// node, the method itself or MethodAny.
+func (n *Node) handlerMethod(method string) string {
+	if _, ok := n.handlers[method]; !ok && n.conditions[method] == nil {
+		return MethodAny
+	}
+	return method
+}
+
+// Handles reports whether the method is handled on the node, by its own
+// handlers or by the handlers of MethodAny, with or without matchers. Unlike
+// GetHandler, it also sees the handlers registered with Mux.HandleWith.
+//
+//  mx.HandleWith("GET", "/admin", adminHandleFunc, mux.Header("X-Admin", "1"))
+//  mx.Trie().Parse("/admin").Handles("GET") // true
+func (n *Node) Handles(method string) bool {
+	for _, m := range []string{method, MethodAny} {
+		if _, ok := n.handlers[m]; ok || n.conditions[m] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// methods returns the methods handled on the node, with or without matchers.
+func (n *Node) methods() []string {
+	methods := make([]string, 0, len(n.handlers)+len(n.conditions))
+	for method := range n.handlers {
+		methods = append(methods, method)
+	}
+	for method := range n.conditions {
+		if _, ok := n.handlers[method]; !ok {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// GetAllow returns allow methods defined on the node, MethodAny is not included.
 //
 //  trie := New()
 //  trie.Parse("/").Handle("GET", handler1)
@@ -457,38 +804,69 @@ func (n *Node) GetAllow() []string {
 }
 
 // parsePattern support multi pattern
-func parsePattern(parent *Node, segments []string) *Node {
+func parsePattern(parent *Node, segments []string) (*Node, error) {
 	segment := segments[0]
 	segments = segments[1:]
-	child := parseSegment(parent, segment)
+	child, err := parseSegment(parent, segment)
+	if err != nil {
+		return nil, err
+	}
 	if len(segments) == 0 {
 		child.endpoint = true
-		return child
+		return child, nil
 	}
 	return parsePattern(child, segments)
 }
 
-func matchNode(parent *Node, segment, path string) (child *Node) {
+// matchNode returns the child of parent matching the segment, path is
+// the rest of the path after the segment. The candidates are recorded in x
+// if it is not nil.
+func matchNode(parent *Node, segment, path string, x *Explanation) (child *Node) {
 	if child = parent.getChild(segment); child != nil {
+		x.step("match", segment, child, "static child")
 		return
 	}
 	for _, child = range parent.segChildren {
+		// only an optional param matches the empty last segment
+		if segment == "" && (!child.optional || len(path) > 0) {
+			x.step("skip", segment, child, "the segment is empty")
+			continue
+		}
 		if len(path) > 0 && len(child.children) == 0 &&
 			len(child.varyChildren) == 0 && len(child.segChildren) == 0 && len(child.optionChildren) == 0 {
+			x.step("skip", segment, child, "the path continues but the node has no children")
 			continue
 		}
 		if child.regex != nil && !child.regex.MatchString(segment) {
+			x.step("skip", segment, child, "the segment does not match the regexp")
 			continue
 		}
-		if len(path) == 0 && len(child.optionChildren) == 0 && len(child.handlers) == 0 {
+		if len(path) == 0 && len(child.optionChildren) == 0 && len(child.handlers) == 0 && len(child.conditions) == 0 {
+			x.step("skip", segment, child, "the path ends but the node has no handler")
 			continue
 		}
+		x.step("match", segment, child, "param")
 		return child
 	}
 	for _, child = range parent.varyChildren {
-		if child.regex != nil && !child.regex.MatchString(segment) {
+		if child.wildcard && len(child.name) == 2 {
+			// *.* matches the rest of the path
+			if !child.regex.MatchString(segment + path) {
+				x.step("skip", segment, child, "the rest of the path has no extension")
+				continue
+			}
+		} else if child.optional && segment == "" && len(path) == 0 {
+			x.step("match", segment, child, "optional regexp param")
+			return
+		} else if child.regex != nil && !child.regex.MatchString(segment) {
+			x.step("skip", segment, child, "the segment does not match the regexp")
 			continue
 		}
+		if child.wildcard {
+			x.step("match", segment, child, "wildcard")
+		} else {
+			x.step("match", segment, child, "regexp")
+		}
 		return
 	}
 	return nil
@@ -502,16 +880,19 @@ func matchNode(parent *Node, segment, path string) (child *Node) {
 // :name:string
 // *.*
 // *
+// *name
 // cms_:id([0-9]+).html
-func parseSegment(parent *Node, segment string) *Node {
+func parseSegment(parent *Node, segment string) (*Node, error) {
 	if node := parent.getChild(segment); node != nil {
-		return node
+		return node, nil
 	}
 	node := &Node{
 		segment:  segment,
 		parent:   parent,
 		children: make(map[string]*Node),
 		handlers: make(map[string]interface{}),
+		// adding order among the params and regexp params of the parent
+		order: len(parent.segChildren) + len(parent.optionChildren) + len(parent.varyChildren),
 	}
 	// route "/a/" match the last segment empty
 	if segment == "" {
@@ -525,40 +906,51 @@ func parseSegment(parent *Node, segment string) *Node {
 		node.wildcard = true
 		node.regex = wildRegexp
 		node.name = []string{":splat"}
-		parent.varyChildren = append(parent.varyChildren, node)
+		parent.varyChildren = appendByRank(parent.varyChildren, node)
 	} else if segment == "*.*" {
 		node.wildcard = true
 		node.regex = extWildRegexp
 		node.name = []string{":path", ":ext"}
-		parent.varyChildren = append(parent.varyChildren, node)
+		parent.varyChildren = appendByRank(parent.varyChildren, node)
+	} else if wildParamRegexp.MatchString(segment) {
+		node.wildcard = true
+		node.regex = wildRegexp
+		node.name = []string{":" + segment[1:]}
+		parent.varyChildren = appendByRank(parent.varyChildren, node)
 	} else if optionalParamRegexp.MatchString(segment) {
 		node.optional = true
 		node.name = []string{segment[1:]}
-		parent.optionChildren = append(parent.optionChildren, node)
-		parent.segChildren = append(parent.segChildren, node)
+		parent.optionChildren = appendByRank(parent.optionChildren, node)
+		parent.segChildren = appendByRank(parent.segChildren, node)
 	} else if paramRegexp.MatchString(segment) {
 		node.name = []string{segment}
-		parent.segChildren = append(parent.segChildren, node)
+		parent.segChildren = appendByRank(parent.segChildren, node)
 	} else if strings.ContainsAny(segment, ":") {
-		node.name, node.regex, node.optional = regexpSegment(segment)
+		names, regex, optional, err := parseRegexpSegment(segment)
+		if err != nil {
+			return nil, err
+		}
+		node.name, node.regex, node.optional = names, regex, optional
 		if node.optional {
-			parent.optionChildren = append(parent.optionChildren, node)
+			parent.optionChildren = appendByRank(parent.optionChildren, node)
 		}
-		parent.varyChildren = append(parent.varyChildren, node)
+		parent.varyChildren = appendByRank(parent.varyChildren, node)
 	} else {
 		parent.children[segment] = node
 	}
-	return node
+	return node, nil
 }
 
-func regexpSegment(seg string) (params []string, r *regexp.Regexp, optional bool) {
+// parseRegexpSegment compiles a segment containing params into a regexp.
+// Errors are *ErrInvalidPattern with the column relative to seg.
+func parseRegexpSegment(seg string) (params []string, r *regexp.Regexp, optional bool, err error) {
 	var (
 		expr     []rune
 		start    bool
 		startexp bool
+		expstart int
 		param    []rune
 		skipnum  int
-		err      error
 	)
 	for i, v := range seg {
 		if skipnum > 0 {
@@ -570,11 +962,7 @@ func regexpSegment(seg string) (params []string, r *regexp.Regexp, optional bool
 			if v == ':' {
 				if len(seg) >= i+4 {
 					if seg[i+1:i+4] == "int" {
-						intRule := "([0-9]+)"
-						if optional {
-							intRule = "([0-9]+)"
-						}
-						expr = append(expr, []rune(intRule)...)
+						expr = append(expr, []rune("([0-9]+)")...)
 						params = append(params, ":"+string(param))
 						start = false
 						startexp = false
@@ -585,11 +973,7 @@ func regexpSegment(seg string) (params []string, r *regexp.Regexp, optional bool
 				}
 				if len(seg) >= i+7 {
 					if seg[i+1:i+7] == "string" {
-						stringRule := `([\w]+)`
-						if optional {
-							stringRule = `([\w]*)`
-						}
-						expr = append(expr, []rune(stringRule)...)
+						expr = append(expr, []rune(`([\w]+)`)...)
 						params = append(params, ":"+string(param))
 						start = false
 						startexp = false
@@ -603,11 +987,15 @@ func regexpSegment(seg string) (params []string, r *regexp.Regexp, optional bool
 				param = append(param, v)
 				continue
 			}
-			// param name scan finish
+			// param name scan finish, the param matches up to the next
+			// character unless a regexp follows
 			if len(param) > 0 {
 				params = append(params, ":"+string(param))
 				param = make([]rune, 0)
 				start = false
+				if v != '(' {
+					expr = append(expr, []rune("(.+)")...)
+				}
 			}
 		}
 		if startexp {
@@ -622,6 +1010,7 @@ func regexpSegment(seg string) (params []string, r *regexp.Regexp, optional bool
 		} else if v == '(' {
 			startexp = true
 			start = false
+			expstart = i
 			expr = append(expr, '(')
 		} else if v == ')' {
 			startexp = false
@@ -630,9 +1019,6 @@ func regexpSegment(seg string) (params []string, r *regexp.Regexp, optional bool
 		} else if v == '?' && len(seg)-1 > i && seg[i+1] == ':' {
 			optional = true
 		} else {
-			if len(expr) == 0 && len(params) > 0 {
-				expr = []rune("(.+)")
-			}
 			expr = append(expr, v)
 		}
 	}
@@ -644,20 +1030,68 @@ func regexpSegment(seg string) (params []string, r *regexp.Regexp, optional bool
 	}
 	r, err = regexp.Compile(string(expr))
 	if err != nil {
-		panic(fmt.Errorf(`Wrong regexp format: "%s"`, string(expr)))
+		return nil, nil, false, &ErrInvalidPattern{
+			Pattern: seg,
+			Column:  expstart + 1,
+			Reason:  fmt.Sprintf("wrong regexp format %q", string(expr)),
+		}
 	}
 	// valid all params
 	for _, p := range params {
 		if !paramRegexp.MatchString(p) {
-			panic(fmt.Errorf(`Wrong param format: "%s"`, p))
+			return nil, nil, false, &ErrInvalidPattern{
+				Pattern: seg,
+				Column:  strings.Index(seg, p) + 1,
+				Reason:  fmt.Sprintf("wrong param format %q", p),
+			}
 		}
 	}
 	return
 }
 
+// validatePattern checks the pattern before any node is created for it,
+// so that an invalid pattern never leaves a partial route in the trie.
+func validatePattern(pattern string) error {
+	if i := strings.Index(pattern, "//"); i >= 0 {
+		return &ErrInvalidPattern{Pattern: pattern, Column: i + 1, Reason: "multi-slash exist"}
+	}
+	offset := 0
+	if strings.HasPrefix(pattern, "/") {
+		offset = 1
+	}
+	for _, segment := range strings.Split(pattern[offset:], "/") {
+		if isRegexpSegment(segment) {
+			if _, _, _, err := parseRegexpSegment(segment); err != nil {
+				e := err.(*ErrInvalidPattern)
+				e.Pattern = pattern
+				e.Column += offset
+				return e
+			}
+		}
+		offset += len(segment) + 1
+	}
+	return nil
+}
+
+// isWildcardSegment reports whether the segment is *, *.* or *name.
+func isWildcardSegment(segment string) bool {
+	return segment == "*" || segment == "*.*" || wildParamRegexp.MatchString(segment)
+}
+
+// isRegexpSegment reports whether parseSegment compiles the segment with parseRegexpSegment.
+func isRegexpSegment(segment string) bool {
+	return segment != "" && !strings.Contains(segment, "::") && !isWildcardSegment(segment) &&
+		!optionalParamRegexp.MatchString(segment) && !paramRegexp.MatchString(segment) &&
+		strings.ContainsAny(segment, ":")
+}
+
 func pathClean(path string) string {
 	if !strings.Contains(path, "//") {
 		return path
 	}
-	return strings.Replace(path, `//`, "/", -1)
+	// a single Replace leaves "//" of "///"
+	for strings.Contains(path, "//") {
+		path = strings.Replace(path, "//", "/", -1)
+	}
+	return path
 }
@@ -23,10 +23,15 @@ func TestRegexpSegment(t *testing.T) {
 		"cms_:id(.+)_:page(.+).html": {[]string{":id", ":page"}, `cms_(.+)_(.+).html`, false},
 		`:app(a|b|c)`:                {[]string{":app"}, `(a|b|c)`, false},
 		`:app\((a|b|c)\)`:            {[]string{":app"}, `(.+)\((a|b|c)\)`, false},
+		"v-:major-:minor":            {[]string{":major", ":minor"}, `v-(.+)-(.+)`, false},
+		"cms_:id([0-9]+)-:slug.html": {[]string{":id", ":slug"}, `cms_([0-9]+)-(.+).html`, false},
 	}
 
 	for pattern, v := range items {
-		w, r, o := regexpSegment(pattern)
+		w, r, o, err := parseRegexpSegment(pattern)
+		if err != nil {
+			t.Fatalf("%s should compile, got %v", pattern, err)
+		}
 		if o != v.optional || r.String() != v.regStr || strings.Join(w, ",") != strings.Join(v.params, ",") {
 			t.Fatalf("%s should return %s,%q,%t got %s,%q,%t", pattern, v.params, v.regStr, v.optional, w, r.String(), o)
 		}
@@ -57,6 +62,10 @@ var routers = []struct {
 	{"/cc/*/dd", "/cc/2009/11/dd", map[string]string{":splat": "2009/11"}, false, false},
 	{"/cc/:id/*", "/cc/2009/11/dd", map[string]string{":id": "2009", ":splat": "11/dd"}, false, false},
 	{"/ee/:year/*/ff", "/ee/2009/11/ff", map[string]string{":year": "2009", ":splat": "11"}, false, false},
+	{"/files/*filepath", "/files/css/app.css", map[string]string{":filepath": "css/app.css"}, false, false},
+	{"/a/*left/b/*right", "/a/x/y/b/z/w", map[string]string{":left": "x/y", ":right": "z/w"}, false, false},
+	{"/a/*left/b/:id/*right", "/a/x/b/7/z", map[string]string{":left": "x", ":id": "7", ":right": "z"}, false, false},
+	{"/gg/*/:id", "/gg/2009/11/7", map[string]string{":splat": "2009/11", ":id": "7"}, false, false},
 	{
 		"/thumbnail/:size/uploads/*",
 		"/thumbnail/100x100/uploads/items/2014/04/20/dPRCdChkUd651t1Hvs18.jpg",
@@ -83,7 +92,9 @@ var routers = []struct {
 	{"/v1/shop/:id/account", "/v1/shop/123/account", map[string]string{":id": "123"}, false, false},
 	{"/v1/shop/:name:string", "/v1/shop/nike", map[string]string{":name": "nike"}, false, false},
 	{"/v1/shop/:id([0-9]+)", "/v1/shop//123", map[string]string{":id": "123"}, true, true},
+	{"/v1/shop/:id([0-9]+)", "/v1/shop///123", map[string]string{":id": "123"}, true, true},
 	{"/v1/shop/:id([0-9]+)_:name", "/v1/shop/123_nike", map[string]string{":id": "123", ":name": "nike"}, false, false},
+	{"/v1/shop/v-:major-:minor", "/v1/shop/v-1-2-3", map[string]string{":major": "1-2", ":minor": "3"}, false, true},
 	{"/v1/shop/:id(.+)_cms.html", "/v1/shop/123_cms.html", map[string]string{":id": "123"}, false, false},
 	{"/v1/shop/cms_:id(.+)_:page(.+).html", "/v1/shop/cms_123_1.html", map[string]string{":id": "123", ":page": "1"}, false, false},
 	{"/v1/:v/cms/aaa_:id(.+)_:page(.+).html", "/v1/2/cms/aaa_123_1.html", map[string]string{":v": "2", ":id": "123", ":page": "1"}, false, false},
@@ -145,6 +156,28 @@ func TestBuildURL(t *testing.T) {
 	}
 }
 
+func TestBuildURLParenValue(t *testing.T) {
+	// the value of :id has parens, which are not groups of the regexp
+	u, err := NewTrie().Parse("/shop/:id(.+)-:name(.+)").BuildURL(":id", "(1)", ":name", "nike")
+	if err != nil || u.Path != "/shop/(1)-nike" {
+		t.Fatalf("should build /shop/(1)-nike, got %v %v", u, err)
+	}
+}
+
+func TestBuildURLEmptyOptional(t *testing.T) {
+	// the params of /o/ are empty, the optional segment is left out
+	tr := NewTrie()
+	n := tr.Parse("/o/?:major:int.:minor:int")
+	m, err := tr.Match("/o/")
+	if err != nil || m.Node != n {
+		t.Fatalf("/o/ should match, got %+v %v", m, err)
+	}
+	u, err := n.BuildURL(":major", m.Params[":major"], ":minor", m.Params[":minor"])
+	if err != nil || u.Path != "/o" {
+		t.Fatalf("should build /o, got %v %v", u, err)
+	}
+}
+
 func TestUnmatched(t *testing.T) {
 	var unrouters = []struct {
 		url        string
@@ -174,3 +207,173 @@ func mapToSlice(m map[string]string) (s []string) {
 	}
 	return s
 }
+
+func TestTryParse(t *testing.T) {
+	items := []struct {
+		pattern string
+		column  int
+	}{
+		{"/a//b", 3},
+		{"/a/:id([0-9]+", 7},
+		{"/v1/shop/cms_:id([0-9)_:page", 17},
+		{"/a/:id(\\Q)", 7},
+	}
+	for _, v := range items {
+		tr := NewTrie()
+		n, err := tr.TryParse(v.pattern)
+		if n != nil || err == nil {
+			t.Fatalf("%s should return error, got %v", v.pattern, n)
+		}
+		e, ok := err.(*ErrInvalidPattern)
+		if !ok {
+			t.Fatalf("%s should return *ErrInvalidPattern, got %T", v.pattern, err)
+		}
+		if e.Pattern != v.pattern || e.Column != v.column {
+			t.Fatalf("%s should fail at column %d, got %q at column %d", v.pattern, v.column, e.Pattern, e.Column)
+		}
+		if len(tr.root.children) != 0 || len(tr.root.varyChildren) != 0 {
+			t.Fatalf("%s should not leave nodes in trie", v.pattern)
+		}
+	}
+}
+
+func TestTryParseNonCapturingGroup(t *testing.T) {
+	// a param with a non capturing group is accepted, as it used to be
+	if _, err := NewTrie().TryParse("/a/:id(?:[0-9]+)"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTryParseInvalidRegexp(t *testing.T) {
+	patterns := []string{
+		"/a/:id(\\Q)", "/a/\\Q:", "/a/:id([0-9]+", "/a/:id(\\Qx\\E)", "/a/:id(\\pL)-:b(\\PN)", "/a/x\\Q:id",
+	}
+	for _, caseSensitive := range []bool{true, false} {
+		for _, pattern := range patterns {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("%s should not panic with CaseSensitive %t, got %v", pattern, caseSensitive, r)
+					}
+				}()
+				tr := NewTrie(Options{CaseSensitive: caseSensitive})
+				if _, err := tr.TryParse(pattern); err != nil {
+					if _, ok := err.(*ErrInvalidPattern); !ok {
+						t.Fatalf("%s should return *ErrInvalidPattern, got %T", pattern, err)
+					}
+				}
+			}()
+		}
+	}
+	// "\Q" is lowercased to the invalid escape "\q"
+	if _, err := NewTrie(Options{CaseSensitive: true}).TryParse("/a/:id(\\Qx\\E)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTrie(Options{}).TryParse("/a/:id(\\Qx\\E)"); err == nil {
+		t.Fatal("/a/:id(\\Qx\\E) should be invalid when case insensitive")
+	}
+}
+
+func TestTryHandle(t *testing.T) {
+	tr := NewTrie()
+	n := tr.Parse("/a/:id")
+	if err := n.TryHandle("GET", "astaxie"); err != nil {
+		t.Fatal(err)
+	}
+	err := tr.Parse("/a/:id").TryHandle("GET", "asta")
+	if e, ok := err.(*ErrDuplicateRoute); !ok || e.Pattern != "/a/:id" || e.Method != "GET" {
+		t.Fatalf("should return *ErrDuplicateRoute, got %#v", err)
+	}
+	if _, err = n.TryName("a"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = tr.Parse("/b").TryName("a")
+	if e, ok := err.(*ErrDuplicateName); !ok || e.Name != "a" || e.Pattern != "/a/:id" {
+		t.Fatalf("should return *ErrDuplicateName, got %#v", err)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	tr := NewTrie()
+	tr.Parse("/users/:name:string").Handle("GET", "name")
+	tr.Parse("/users/:id:int").Priority(10).Handle("GET", "id")
+	tr.Parse("/users/:slug([a-z0-9]+)").Handle("GET", "slug")
+	tr.Parse("/teams/:team/:name:string").Priority(-1).Handle("GET", "team name")
+	tr.Parse("/teams/:team/:id:int").Handle("GET", "team id")
+	tr.Parse("/groups/:group/members").Handle("GET", "members")
+	tr.Parse("/groups/:org/teams").Priority(5).Handle("GET", "teams")
+
+	items := map[string]string{
+		"/users/123":      "id",
+		"/users/abc":      "name",
+		"/teams/a/123":    "team id",
+		"/teams/a/abc":    "team name",
+		"/groups/a/teams": "teams",
+	}
+	for path, handler := range items {
+		m, err := tr.Match(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Node == nil || m.Node.GetHandler("GET").(string) != handler {
+			t.Fatalf("%s should match %q, got %#v", path, handler, m.Node)
+		}
+	}
+	// the priority moves the whole :org subtree first, the trie does not
+	// backtrack to :group when "members" is not under :org
+	if m, err := tr.Match("/groups/a/members"); err != nil || m.Node != nil {
+		t.Fatalf("/groups/a/members should be shadowed by /groups/:org/teams, got %v %v", m, err)
+	}
+	if tr.Parse("/users/:id:int").GetPriority() != 10 {
+		t.Fatal("priority should be 10")
+	}
+}
+
+func TestPriorityTies(t *testing.T) {
+	tr := NewTrie()
+	tr.Parse("/a/:x([0-9]+)").Handle("GET", "x")
+	tr.Parse("/a/:y([0-9]+)").Handle("GET", "y")
+	tr.Parse("/a/:z([0-9]+)").Handle("GET", "z")
+	// equal priorities are tried in adding order, whatever the order of the
+	// Priority calls
+	tr.Parse("/a/:z([0-9]+)").Priority(1)
+	tr.Parse("/a/:y([0-9]+)").Priority(1)
+	if m, _ := tr.Match("/a/1"); m.Node == nil || m.Node.GetHandler("GET") != "y" {
+		t.Fatalf("should match y, got %#v", m.Node)
+	}
+	tr.Parse("/a/:y([0-9]+)").Priority(0)
+	tr.Parse("/a/:z([0-9]+)").Priority(0)
+	if m, _ := tr.Match("/a/1"); m.Node == nil || m.Node.GetHandler("GET") != "x" {
+		t.Fatalf("should match x, got %#v", m.Node)
+	}
+}
+
+func TestExtWildcard(t *testing.T) {
+	tr := NewTrie()
+	tr.Parse("/abc/*.*").Handle("GET", "ext")
+	items := map[string]map[string]string{
+		"/abc/xyz.json":      {":path": "xyz", ":ext": "json"},
+		"/abc/123/xyz.html":  {":path": "123/xyz", ":ext": "html"},
+		"/abc/app.min.js":    {":path": "app", ":ext": "min.js"},
+		"/abc/xyz":           nil,
+		"/abc/123/xyz":       nil,
+		"/abc/.htaccess":     nil,
+		"/abc/xyz.":          nil,
+		"/abc/js/app.min.js": {":path": "js/app", ":ext": "min.js"},
+	}
+	for path, params := range items {
+		m, err := tr.Match(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if params == nil {
+			if m.Node != nil {
+				t.Fatalf("%s should not match, got %v", path, m.Params)
+			}
+			continue
+		}
+		if m.Node == nil || m.Params[":path"] != params[":path"] || m.Params[":ext"] != params[":ext"] {
+			t.Fatalf("%s should match %v, got %v", path, params, m.Params)
+		}
+	}
+}
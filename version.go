@@ -0,0 +1,211 @@
+package mux
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionExtractor returns the API version requested by a request,
+// or an empty string if the request does not ask for a version.
+type VersionExtractor func(req *http.Request) string
+
+// HeaderVersion extracts the version from a header, e.g. "API-Version: 2".
+func HeaderVersion(name string) VersionExtractor {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// AcceptVersion extracts the version from a vendor media type in the Accept
+// header, e.g. AcceptVersion("vnd.company") extracts "2" from
+// "Accept: application/vnd.company.v2+json".
+func AcceptVersion(vendor string) VersionExtractor {
+	r := regexp.MustCompile(`/` + regexp.QuoteMeta(vendor) + `\.v([0-9.]+)(\+|;|,|$)`)
+	return func(req *http.Request) string {
+		if m := r.FindStringSubmatch(req.Header.Get("Accept")); m != nil {
+			return m[1]
+		}
+		return ""
+	}
+}
+
+// PathVersion extracts the version from a param of the pattern, e.g.
+// PathVersion(":version") extracts "1" from "/v1/users" with
+// the pattern "/:version/users".
+func PathVersion(param string) VersionExtractor {
+	return func(req *http.Request) string {
+		return Param(req, param)
+	}
+}
+
+// VersionWith sets the extractors used by versioned routes of the Mux,
+// the first version found is used.
+//
+//  mx.VersionWith(mux.AcceptVersion("vnd.company"), mux.HeaderVersion("API-Version"))
+func (m *Mux) VersionWith(extractors ...VersionExtractor) {
+	m.versionExtractors = extractors
+}
+
+// Versioned registers a route whose handler depends on the requested version.
+// The handler of the requested version is used, or else of the nearest lower
+// version. The latest version is used when the request asks for no version.
+//
+//  mx.Versioned("GET", "/users/:id").
+//  	Version("1", showUserV1).
+//  	Version("2", showUserV2).
+//  	Deprecate("1", deprecatedAt, sunsetAt)
+func (m *Mux) Versioned(method, pattern string) *VersionedRoute {
+	v := &VersionedRoute{mux: m}
+	v.node = m.Handle(method, pattern, v.ServeHTTP)
+	return v
+}
+
+// VersionedRoute is a route with a handler per version.
+type VersionedRoute struct {
+	mux      *Mux
+	node     *Node
+	versions []*versionHandler
+}
+
+type versionHandler struct {
+	version       string
+	number        []int
+	handler       http.HandlerFunc
+	deprecated    bool
+	since, sunset time.Time
+}
+
+// Node returns the endpoint node of the route.
+func (v *VersionedRoute) Node() *Node {
+	return v.node
+}
+
+// Version registers the handler of a version, such as "2" or "2.1".
+// It panics if the version is invalid or already registered.
+func (v *VersionedRoute) Version(version string, handler http.HandlerFunc) *VersionedRoute {
+	number, err := parseVersion(version)
+	if err != nil {
+		panic(err)
+	}
+	i := 0
+	for ; i < len(v.versions); i++ {
+		c := compareVersion(number, v.versions[i].number)
+		if c == 0 {
+			panic(fmt.Errorf("mux: version %q already defined", version))
+		}
+		if c < 0 {
+			break
+		}
+	}
+	v.versions = append(v.versions, nil)
+	copy(v.versions[i+1:], v.versions[i:])
+	v.versions[i] = &versionHandler{version: version, number: number, handler: handler}
+	return v
+}
+
+// Deprecate marks a registered version as deprecated. Responses of the version
+// have a Deprecation header with the since date, or "true" if it is zero,
+// and a Sunset header with the sunset date if it is not zero.
+// It panics if the version is not registered.
+func (v *VersionedRoute) Deprecate(version string, since, sunset time.Time) *VersionedRoute {
+	number, err := parseVersion(version)
+	if err != nil {
+		panic(err)
+	}
+	for _, h := range v.versions {
+		if compareVersion(h.number, number) == 0 {
+			h.deprecated, h.since, h.sunset = true, since, sunset
+			return v
+		}
+	}
+	panic(fmt.Errorf("mux: version %q not defined", version))
+}
+
+// ServeHTTP runs the handler of the requested version.
+func (v *VersionedRoute) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	requested := ""
+	for _, extract := range v.mux.versionExtractors {
+		if requested = extract(req); requested != "" {
+			break
+		}
+	}
+	h, err := v.lookup(requested)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if h == nil {
+		http.Error(w, fmt.Sprintf(`version "%s" not supported`, requested), http.StatusNotFound)
+		return
+	}
+	if h.deprecated {
+		if h.since.IsZero() {
+			w.Header().Set("Deprecation", "true")
+		} else {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(h.since.Unix(), 10))
+		}
+		if !h.sunset.IsZero() {
+			w.Header().Set("Sunset", h.sunset.UTC().Format(http.TimeFormat))
+		}
+	}
+	h.handler(w, req)
+}
+
+// lookup returns the handler of the version or of the nearest lower version,
+// the latest one if the version is empty.
+func (v *VersionedRoute) lookup(version string) (*versionHandler, error) {
+	if len(v.versions) == 0 {
+		return nil, nil
+	}
+	if version == "" {
+		return v.versions[len(v.versions)-1], nil
+	}
+	number, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(v.versions) - 1; i >= 0; i-- {
+		if compareVersion(v.versions[i].number, number) <= 0 {
+			return v.versions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// parseVersion parses versions like "2", "v2" and "2.1".
+func parseVersion(version string) ([]int, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.ToLower(version), "v"), ".")
+	number := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf(`mux: invalid version "%s"`, version)
+		}
+		number[i] = n
+	}
+	return number, nil
+}
+
+// compareVersion compares versions part by part, missing parts are 0.
+func compareVersion(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
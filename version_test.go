@@ -0,0 +1,61 @@
+package mux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVersioned(t *testing.T) {
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}
+	}
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mux := New()
+	mux.VersionWith(PathVersion(":version"), AcceptVersion("vnd.company"), HeaderVersion("API-Version"))
+	mux.Versioned("GET", "/users/:id").
+		Version("2", handler("v2")).
+		Version("1", handler("v1")).
+		Version("2.1", handler("v2.1")).
+		Deprecate("1", since, sunset)
+	mux.Versioned("GET", "/:version/users").
+		Version("1", handler("v1")).
+		Version("3", handler("v3"))
+
+	items := []struct {
+		path, header, value string
+		code                int
+		body                string
+		deprecation         string
+	}{
+		{"/users/1", "", "", 200, "v2.1", ""},
+		{"/users/1", "API-Version", "1", 200, "v1", "@1704067200"},
+		{"/users/1", "API-Version", "2", 200, "v2", ""},
+		{"/users/1", "API-Version", "v2.0", 200, "v2", ""},
+		{"/users/1", "API-Version", "5", 200, "v2.1", ""},
+		{"/users/1", "API-Version", "0", 404, "version \"0\" not supported\n", ""},
+		{"/users/1", "API-Version", "x", 400, "mux: invalid version \"x\"\n", ""},
+		{"/users/1", "Accept", "application/vnd.company.v1+json", 200, "v1", "@1704067200"},
+		{"/v2/users", "", "", 200, "v1", ""},
+		{"/v3/users", "API-Version", "1", 200, "v3", ""},
+	}
+	for _, v := range items {
+		req := httptest.NewRequest("GET", v.path, nil)
+		if v.header != "" {
+			req.Header.Set(v.header, v.value)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != v.code || w.Body.String() != v.body || w.Header().Get("Deprecation") != v.deprecation {
+			t.Fatalf("%s %s=%s should respond %d %q, got %d %q %v", v.path, v.header, v.value, v.code, v.body, w.Code, w.Body.String(), w.Header())
+		}
+		if v.deprecation != "" && w.Header().Get("Sunset") != "Wed, 01 Jan 2025 00:00:00 GMT" {
+			t.Fatalf("should set Sunset header, got %v", w.Header())
+		}
+	}
+}